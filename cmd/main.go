@@ -30,7 +30,10 @@ import (
 	"github.com/XORbit01/jobseeker-backend/db"
 	_ "github.com/XORbit01/jobseeker-backend/docs"
 	"github.com/XORbit01/jobseeker-backend/handlers"
+	"github.com/XORbit01/jobseeker-backend/mailer"
 	"github.com/XORbit01/jobseeker-backend/middleware"
+	"github.com/XORbit01/jobseeker-backend/realtime"
+	"github.com/XORbit01/jobseeker-backend/repos"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 	ginSwagger "github.com/swaggo/gin-swagger"
@@ -67,6 +70,11 @@ func main() {
 
 	router := gin.Default()
 	router.RedirectFixedPath = false
+
+	// Client IPs, used for login throttling and sessions, come from X-Forwarded-For only behind these proxies
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
 	
 	// Global middleware (order matters!)
 	router.Use(middleware.CORS(cfg.AllowedOrigins))
@@ -77,17 +85,28 @@ func main() {
 	
 	apiGroup := router.Group(cfg.APIPrefix)
 
+	// real-time events, shared by chat, applications and the event stream, and
+	// closed by the auth and user routes when sessions end
+	hub := realtime.NewHub()
+
+	// Access token signing keys, published for other services at /.well-known/jwks.json
+	tokenKeys, err := middleware.LoadTokenKeys(cfg)
+	if err != nil {
+		log.Fatalf("Failed to load token signing keys: %v", err)
+	}
+	handlers.RegisterJWKSRoutes(router.Group("/.well-known"), tokenKeys)
+
 	authGroup := apiGroup.Group("/auth") // /api/auth
-	handlers.RegisterAuthRoutes(authGroup, database)
+	handlers.RegisterAuthRoutes(authGroup, database, cfg, mailer.New(cfg), tokenKeys, hub)
 
 	protectedGroup := apiGroup.Group("/")
-	protectedGroup.Use(middleware.AuthMiddleware())
+	protectedGroup.Use(middleware.AuthMiddleware(tokenKeys, repos.NewSessionRepository(database)))
 	// image upload
 	protectedGroup.POST("/upload", handlers.UploadFile)
 
 	// User routes
 	userGroup := protectedGroup.Group("/users")
-	handlers.RegisterUserRoutes(userGroup, database)
+	handlers.RegisterUserRoutes(userGroup, database, hub)
 
 	// Employer profile route
 	employerGroup := protectedGroup.Group("/employers")
@@ -103,16 +122,18 @@ func main() {
 
 	// jobs private
 	privateJobGroup := protectedGroup.Group("/jobs")
-	handlers.RegisterJobRoutesPrivate(privateJobGroup, database)
+	handlers.RegisterJobRoutesPrivate(privateJobGroup, database, cfg)
+
+	handlers.RegisterEventRoutes(protectedGroup, database, hub)
 
 	applicationGroup := protectedGroup.Group("/applications")
-	handlers.RegisterApplicationRoutes(applicationGroup, database)
+	handlers.RegisterApplicationRoutes(applicationGroup, database, hub, cfg)
 	// profile public
 	publicProfileGroup := apiGroup.Group("/profile")
 	handlers.RegisterPublicProfileRoutes(publicProfileGroup, database)
 
 	// chat
-	handlers.RegisterChatRoutes(protectedGroup, database)
+	handlers.RegisterChatRoutes(protectedGroup, database, hub, cfg)
 
 	// swagger files
 	router.GET("/docs/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
@@ -0,0 +1,290 @@
+// Command mock-oidc is a minimal OpenID Connect provider for trying out and testing social login
+// locally. Its login page accepts any email address without a password, so never expose it.
+//
+// Run it with
+//
+//	go run ./cmd/mock-oidc
+//
+// and configure the API with
+//
+//	OIDC_PROVIDERS=mock
+//	OIDC_MOCK_ISSUER=http://localhost:9090
+//	OIDC_MOCK_CLIENT_ID=career-pulse
+package main
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/XORbit01/jobseeker-backend/jwk"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	keyID   = "mock-key"
+	codeTTL = time.Minute
+)
+
+// authorization is an issued code waiting to be redeemed at the token endpoint
+type authorization struct {
+	clientID      string
+	redirectURI   string
+	codeChallenge string
+	nonce         string
+	email         string
+	emailVerified bool
+	expiresAt     time.Time
+}
+
+type server struct {
+	issuer       string
+	clientID     string
+	clientSecret string
+	key          *rsa.PrivateKey
+
+	mu    sync.Mutex
+	codes map[string]authorization
+}
+
+var loginPage = template.Must(template.New("login").Parse(`<!DOCTYPE html>
+<html>
+<head><title>Mock OIDC login</title></head>
+<body style="font-family: sans-serif; max-width: 28em; margin: 4em auto">
+	<h1>Mock OIDC login</h1>
+	<p>Log in to <b>{{.ClientID}}</b> as any user. No password is needed.</p>
+	<form method="post">
+		{{range $name, $value := .Params}}<input type="hidden" name="{{$name}}" value="{{$value}}">
+		{{end}}
+		<p><label>Email <input type="email" name="email" value="jane@example.com" required></label></p>
+		<p><label><input type="checkbox" name="email_verified" value="true" checked> Email is verified</label></p>
+		<p><button type="submit">Log in</button></p>
+	</form>
+</body>
+</html>
+`))
+
+func main() {
+	addr := flag.String("addr", ":9090", "address to listen on")
+	issuer := flag.String("issuer", "http://localhost:9090", "issuer URL, as the API reaches this server")
+	clientID := flag.String("client-id", "career-pulse", "client ID the API is configured with")
+	clientSecret := flag.String("client-secret", "", "client secret the API must send; empty accepts a public client")
+	flag.Parse()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		log.Fatalf("Failed to generate signing key: %v", err)
+	}
+
+	s := &server{
+		issuer:       strings.TrimSuffix(*issuer, "/"),
+		clientID:     *clientID,
+		clientSecret: *clientSecret,
+		key:          key,
+		codes:        make(map[string]authorization),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", s.discovery)
+	mux.HandleFunc("GET /jwks", s.jwks)
+	mux.HandleFunc("GET /authorize", s.authorizePage)
+	mux.HandleFunc("POST /authorize", s.authorize)
+	mux.HandleFunc("POST /token", s.token)
+
+	log.Printf("Mock OIDC provider %s listening on %s (client ID %q)", s.issuer, *addr, s.clientID)
+	log.Fatal(http.ListenAndServe(*addr, mux))
+}
+
+func (s *server) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"issuer":                                s.issuer,
+		"authorization_endpoint":                s.issuer + "/authorize",
+		"token_endpoint":                        s.issuer + "/token",
+		"jwks_uri":                              s.issuer + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"scopes_supported":                      []string{"openid", "email", "profile"},
+		"token_endpoint_auth_methods_supported": []string{"client_secret_basic", "client_secret_post", "none"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+func (s *server) jwks(w http.ResponseWriter, r *http.Request) {
+	key, err := jwk.FromPublicKey(s.key.Public(), keyID, "RS256")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, jwk.Set{Keys: []jwk.Key{key}})
+}
+
+// authorizePage shows the login form for a valid authorization request
+func (s *server) authorizePage(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	if msg := s.checkAuthorizeParams(params); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	hidden := make(map[string]string)
+	for _, name := range []string{"client_id", "redirect_uri", "state", "nonce", "code_challenge"} {
+		hidden[name] = params.Get(name)
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	loginPage.Execute(w, map[string]any{"ClientID": s.clientID, "Params": hidden})
+}
+
+// authorize logs the user in as the submitted email and sends them back with a code
+func (s *server) authorize(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	params := r.PostForm
+	params.Set("response_type", "code")
+	params.Set("code_challenge_method", "S256")
+	if msg := s.checkAuthorizeParams(params); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	code := randomToken()
+	s.mu.Lock()
+	s.codes[code] = authorization{
+		clientID:      params.Get("client_id"),
+		redirectURI:   params.Get("redirect_uri"),
+		codeChallenge: params.Get("code_challenge"),
+		nonce:         params.Get("nonce"),
+		email:         params.Get("email"),
+		emailVerified: params.Get("email_verified") == "true",
+		expiresAt:     time.Now().Add(codeTTL),
+	}
+	s.mu.Unlock()
+
+	redirect, _ := url.Parse(params.Get("redirect_uri"))
+	query := redirect.Query()
+	query.Set("code", code)
+	query.Set("state", params.Get("state"))
+	redirect.RawQuery = query.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func (s *server) checkAuthorizeParams(params url.Values) string {
+	switch {
+	case params.Get("response_type") != "code":
+		return "response_type must be code"
+	case params.Get("client_id") != s.clientID:
+		return "unknown client_id"
+	case params.Get("redirect_uri") == "":
+		return "redirect_uri is required"
+	case params.Get("code_challenge") == "" || params.Get("code_challenge_method") != "S256":
+		return "a PKCE code_challenge with the S256 method is required"
+	}
+	if _, err := url.ParseRequestURI(params.Get("redirect_uri")); err != nil {
+		return "invalid redirect_uri"
+	}
+	return ""
+}
+
+// token redeems a code for an ID token after checking the client and the PKCE verifier
+func (s *server) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		tokenError(w, "invalid_request", err.Error())
+		return
+	}
+
+	clientID, clientSecret, ok := r.BasicAuth()
+	if ok {
+		clientID, _ = url.QueryUnescape(clientID)
+		clientSecret, _ = url.QueryUnescape(clientSecret)
+	} else {
+		clientID, clientSecret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if clientID != s.clientID || subtle.ConstantTimeCompare([]byte(clientSecret), []byte(s.clientSecret)) != 1 {
+		tokenError(w, "invalid_client", "unknown client or wrong secret")
+		return
+	}
+	if r.PostForm.Get("grant_type") != "authorization_code" {
+		tokenError(w, "unsupported_grant_type", "only authorization_code is supported")
+		return
+	}
+
+	code := r.PostForm.Get("code")
+	s.mu.Lock()
+	auth, found := s.codes[code]
+	delete(s.codes, code)
+	s.mu.Unlock()
+
+	verifier := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	switch {
+	case !found || time.Now().After(auth.expiresAt):
+		tokenError(w, "invalid_grant", "unknown or expired code")
+		return
+	case auth.clientID != clientID || auth.redirectURI != r.PostForm.Get("redirect_uri"):
+		tokenError(w, "invalid_grant", "code was issued to another client or redirect_uri")
+		return
+	case base64.RawURLEncoding.EncodeToString(verifier[:]) != auth.codeChallenge:
+		tokenError(w, "invalid_grant", "code_verifier does not match the code_challenge")
+		return
+	}
+
+	// The subject is derived from the email, so logging in with the same address is the same account
+	subject := sha256.Sum256([]byte(strings.ToLower(auth.email)))
+	now := time.Now()
+	idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":            s.issuer,
+		"sub":            hex.EncodeToString(subject[:10]),
+		"aud":            clientID,
+		"iat":            now.Unix(),
+		"exp":            now.Add(5 * time.Minute).Unix(),
+		"nonce":          auth.nonce,
+		"email":          auth.email,
+		"email_verified": auth.emailVerified,
+		"name":           strings.Split(auth.email, "@")[0],
+	})
+	idToken.Header["kid"] = keyID
+	signed, err := idToken.SignedString(s.key)
+	if err != nil {
+		tokenError(w, "server_error", err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token": randomToken(),
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     signed,
+	})
+}
+
+func tokenError(w http.ResponseWriter, code, description string) {
+	writeJSON(w, http.StatusBadRequest, map[string]string{"error": code, "error_description": description})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func randomToken() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -2,9 +2,27 @@ package config
 
 import (
 	"errors"
+	"fmt"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
+)
+
+// Contact policies for chat
+const (
+	// ContactPolicyRestricted lets employers start conversations only with their applicants or
+	// discoverable candidates, lets job seekers only reply, and lets admins message anyone
+	ContactPolicyRestricted = "restricted"
+	// ContactPolicyOpen lets any user message any other user
+	ContactPolicyOpen = "open"
+)
+
+// Actions that can be restricted to users with a verified email address
+const (
+	VerifiedActionPostJobs  = "post_jobs"
+	VerifiedActionMessaging = "messaging"
 )
 
 type DBConfig struct {
@@ -17,14 +35,57 @@ type DBConfig struct {
 	SSLMode  string
 }
 
+// SMTPConfig configures outgoing email. Without a host, emails are only logged.
+type SMTPConfig struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+// OIDCProviderConfig configures login with an OpenID Connect provider
+type OIDCProviderConfig struct {
+	// Name identifies the provider in URLs and linked identities, such as "google"
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	// RedirectURL is where the provider sends users back to; it must be registered with the provider
+	RedirectURL string
+}
+
 type Config struct {
-	Environment   string
-	Port          string
-	JWTSecret     string
-	DB            DBConfig
-	TokenLifetime string
+	Environment string
+	Port        string
+	JWTSecret   string
+	DB          DBConfig
+	// JWTSigningKeyFile is a PEM file with the RSA or Ed25519 key access tokens are signed with.
+	// Without it, they are signed with JWTSecret using HS256.
+	JWTSigningKeyFile string
+	// JWTVerificationKeyFiles are PEM files with further keys whose tokens are accepted, for rotation
+	JWTVerificationKeyFiles []string
+	// Authentication: short-lived access tokens renewed with rotating refresh tokens
+	TokenLifetime        time.Duration
+	RefreshTokenLifetime time.Duration
+	// Email configuration
+	SMTP SMTPConfig
+	// FrontendURL is where links in emails point to
+	FrontendURL string
+	// VerifiedEmailRequiredFor lists the actions unverified users may not take
+	VerifiedEmailRequiredFor []string
+	// Login throttling: failed attempts allowed per account and per IP before logins are locked
+	// out, and how long the first lockout lasts. Each further failure doubles it.
+	LoginMaxAttempts      int
+	LoginMaxAttemptsPerIP int
+	LoginLockout          time.Duration
+	// OpenID Connect providers users can log in with
+	OIDCProviders []OIDCProviderConfig
 	// Server configuration
 	GinMode string
+	// TrustedProxies are the addresses or CIDR ranges of reverse proxies whose
+	// X-Forwarded-For header is believed. Without any, the connection's address is used.
+	TrustedProxies []string
 	// CORS configuration
 	AllowedOrigins []string
 	// Static files configuration
@@ -36,6 +97,9 @@ type Config struct {
 	// Database connection pool
 	MaxOpenConns int
 	MaxIdleConns int
+	// Chat configuration
+	MessageEditWindow time.Duration
+	ContactPolicy     string
 }
 
 func Load() (*Config, error) {
@@ -51,120 +115,74 @@ func Load() (*Config, error) {
 		env = "development"
 	}
 
-	tokenLifetime := os.Getenv("TOKEN_LIFETIME")
-	if tokenLifetime == "" {
-		tokenLifetime = "24h"
+	tokenLifetime, err := getDuration("TOKEN_LIFETIME", 15*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshTokenLifetime, err := getDuration("REFRESH_TOKEN_LIFETIME", 30*24*time.Hour)
+	if err != nil {
+		return nil, err
 	}
 
 	jwtSecret := os.Getenv("JWT_SECRET")
 	if jwtSecret == "" {
 		return nil, errors.New("JWT_SECRET environment variable is required")
 	}
-	if dsn != "" {
-		// Server configuration
-		ginMode := os.Getenv("GIN_MODE")
-		if ginMode == "" {
-			if env == "production" {
-				ginMode = "release"
-			} else {
-				ginMode = "debug"
-			}
-		}
 
-		// CORS configuration
-		allowedOriginsStr := os.Getenv("ALLOWED_ORIGINS")
-		var allowedOrigins []string
-		if allowedOriginsStr != "" {
-			allowedOrigins = strings.Split(allowedOriginsStr, ",")
-			for i, origin := range allowedOrigins {
-				allowedOrigins[i] = strings.TrimSpace(origin)
-			}
-		}
-
-		// Static files configuration
-		staticPath := os.Getenv("STATIC_PATH")
-		if staticPath == "" {
-			staticPath = "./uploads"
-		}
-
-		staticURL := os.Getenv("STATIC_URL")
-		if staticURL == "" {
-			staticURL = "/static"
-		}
-
-		uploadsPath := os.Getenv("UPLOADS_PATH")
-		if uploadsPath == "" {
-			uploadsPath = "./uploads"
-		}
-
-		// API configuration
-		apiPrefix := os.Getenv("API_PREFIX")
-		if apiPrefix == "" {
-			apiPrefix = "/api"
-		}
-
-		// Database connection pool
-		maxOpenConns := 25
-		if maxOpenConnsStr := os.Getenv("DB_MAX_OPEN_CONNS"); maxOpenConnsStr != "" {
-			if parsed, err := strconv.Atoi(maxOpenConnsStr); err == nil {
-				maxOpenConns = parsed
-			}
-		}
-
-		maxIdleConns := 5
-		if maxIdleConnsStr := os.Getenv("DB_MAX_IDLE_CONNS"); maxIdleConnsStr != "" {
-			if parsed, err := strconv.Atoi(maxIdleConnsStr); err == nil {
-				maxIdleConns = parsed
+	jwtSigningKeyFile := os.Getenv("JWT_SIGNING_KEY_FILE")
+	var jwtVerificationKeyFiles []string
+	if files := os.Getenv("JWT_VERIFICATION_KEY_FILES"); files != "" {
+		for _, file := range strings.Split(files, ",") {
+			if file = strings.TrimSpace(file); file != "" {
+				jwtVerificationKeyFiles = append(jwtVerificationKeyFiles, file)
 			}
 		}
+	}
 
-		return &Config{
-			Environment:    env,
-			Port:           port,
-			JWTSecret:      jwtSecret,
-			TokenLifetime:  tokenLifetime,
-			GinMode:        ginMode,
-			AllowedOrigins: allowedOrigins,
-			StaticPath:     staticPath,
-			StaticURL:      staticURL,
-			UploadsPath:    uploadsPath,
-			APIPrefix:      apiPrefix,
-			MaxOpenConns:   maxOpenConns,
-			MaxIdleConns:   maxIdleConns,
-			DB: DBConfig{
-				DSN: dsn,
-			},
-		}, nil
+	// Email configuration
+	smtpConfig := SMTPConfig{
+		Host:     os.Getenv("SMTP_HOST"),
+		Port:     os.Getenv("SMTP_PORT"),
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     os.Getenv("MAIL_FROM"),
+	}
+	if smtpConfig.Port == "" {
+		smtpConfig.Port = "587"
+	}
+	if smtpConfig.From == "" {
+		smtpConfig.From = "no-reply@localhost"
 	}
 
-	dbHost := os.Getenv("DB_HOST")
-	if dbHost == "" {
-		dbHost = "localhost"
+	frontendURL := strings.TrimSuffix(os.Getenv("FRONTEND_URL"), "/")
+	if frontendURL == "" {
+		frontendURL = "http://localhost:3000"
 	}
 
-	dbPort := os.Getenv("DB_PORT")
-	if dbPort == "" {
-		dbPort = "5432"
+	verifiedEmailRequiredFor, err := getVerifiedActions("EMAIL_VERIFICATION_REQUIRED_FOR")
+	if err != nil {
+		return nil, err
 	}
 
-	dbUser := os.Getenv("DB_USER")
-	if dbUser == "" {
-		return nil, errors.New("DB_USER environment variable is required")
+	loginMaxAttempts, err := getPositiveInt("LOGIN_MAX_ATTEMPTS", 5)
+	if err != nil {
+		return nil, err
 	}
 
-	dbPassword := os.Getenv("DB_PASSWORD")
-	if dbPassword == "" {
-		return nil, errors.New("DB_PASSWORD environment variable is required")
+	loginMaxAttemptsPerIP, err := getPositiveInt("LOGIN_MAX_ATTEMPTS_PER_IP", 50)
+	if err != nil {
+		return nil, err
 	}
 
-	dbName := os.Getenv("DB_NAME")
-	if dbName == "" {
-		return nil, errors.New("DB_NAME environment variable is required")
+	loginLockout, err := getDuration("LOGIN_LOCKOUT", 15*time.Minute)
+	if err != nil {
+		return nil, err
 	}
 
-	dbSSLMode := os.Getenv("DB_SSLMODE")
-	if dbSSLMode == "" {
-		dbSSLMode = "disable"
+	oidcProviders, err := getOIDCProviders("OIDC_PROVIDERS", frontendURL)
+	if err != nil {
+		return nil, err
 	}
 
 	// Server configuration
@@ -177,6 +195,15 @@ func Load() (*Config, error) {
 		}
 	}
 
+	var trustedProxies []string
+	if proxies := os.Getenv("TRUSTED_PROXIES"); proxies != "" {
+		for _, proxy := range strings.Split(proxies, ",") {
+			if proxy = strings.TrimSpace(proxy); proxy != "" {
+				trustedProxies = append(trustedProxies, proxy)
+			}
+		}
+	}
+
 	// CORS configuration
 	allowedOriginsStr := os.Getenv("ALLOWED_ORIGINS")
 	var allowedOrigins []string
@@ -224,26 +251,191 @@ func Load() (*Config, error) {
 		}
 	}
 
-	return &Config{
-		Environment:    env,
-		Port:           port,
-		JWTSecret:      jwtSecret,
-		TokenLifetime:  tokenLifetime,
-		GinMode:        ginMode,
-		AllowedOrigins: allowedOrigins,
-		StaticPath:     staticPath,
-		StaticURL:      staticURL,
-		UploadsPath:    uploadsPath,
-		APIPrefix:      apiPrefix,
-		MaxOpenConns:   maxOpenConns,
-		MaxIdleConns:   maxIdleConns,
-		DB: DBConfig{
-			Host:     dbHost,
-			Port:     dbPort,
-			User:     dbUser,
-			Password: dbPassword,
-			DBName:   dbName,
-			SSLMode:  dbSSLMode,
-		},
-	}, nil
+	// Chat configuration
+	messageEditWindow, err := getDuration("MESSAGE_EDIT_WINDOW", 15*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+
+	contactPolicy := os.Getenv("CHAT_CONTACT_POLICY")
+	switch contactPolicy {
+	case "":
+		contactPolicy = ContactPolicyRestricted
+	case ContactPolicyRestricted, ContactPolicyOpen:
+	default:
+		return nil, fmt.Errorf("CHAT_CONTACT_POLICY must be %q or %q", ContactPolicyRestricted, ContactPolicyOpen)
+	}
+
+	cfg := &Config{
+		Environment:              env,
+		Port:                     port,
+		JWTSecret:                jwtSecret,
+		JWTSigningKeyFile:        jwtSigningKeyFile,
+		JWTVerificationKeyFiles:  jwtVerificationKeyFiles,
+		TokenLifetime:            tokenLifetime,
+		RefreshTokenLifetime:     refreshTokenLifetime,
+		SMTP:                     smtpConfig,
+		FrontendURL:              frontendURL,
+		VerifiedEmailRequiredFor: verifiedEmailRequiredFor,
+		LoginMaxAttempts:         loginMaxAttempts,
+		LoginMaxAttemptsPerIP:    loginMaxAttemptsPerIP,
+		LoginLockout:             loginLockout,
+		OIDCProviders:            oidcProviders,
+		GinMode:                  ginMode,
+		TrustedProxies:           trustedProxies,
+		AllowedOrigins:           allowedOrigins,
+		StaticPath:               staticPath,
+		StaticURL:                staticURL,
+		UploadsPath:              uploadsPath,
+		APIPrefix:                apiPrefix,
+		MaxOpenConns:             maxOpenConns,
+		MaxIdleConns:             maxIdleConns,
+		MessageEditWindow:        messageEditWindow,
+		ContactPolicy:            contactPolicy,
+	}
+
+	if dsn != "" {
+		cfg.DB = DBConfig{
+			DSN: dsn,
+		}
+		return cfg, nil
+	}
+
+	dbHost := os.Getenv("DB_HOST")
+	if dbHost == "" {
+		dbHost = "localhost"
+	}
+
+	dbPort := os.Getenv("DB_PORT")
+	if dbPort == "" {
+		dbPort = "5432"
+	}
+
+	dbUser := os.Getenv("DB_USER")
+	if dbUser == "" {
+		return nil, errors.New("DB_USER environment variable is required")
+	}
+
+	dbPassword := os.Getenv("DB_PASSWORD")
+	if dbPassword == "" {
+		return nil, errors.New("DB_PASSWORD environment variable is required")
+	}
+
+	dbName := os.Getenv("DB_NAME")
+	if dbName == "" {
+		return nil, errors.New("DB_NAME environment variable is required")
+	}
+
+	dbSSLMode := os.Getenv("DB_SSLMODE")
+	if dbSSLMode == "" {
+		dbSSLMode = "disable"
+	}
+
+	cfg.DB = DBConfig{
+		Host:     dbHost,
+		Port:     dbPort,
+		User:     dbUser,
+		Password: dbPassword,
+		DBName:   dbName,
+		SSLMode:  dbSSLMode,
+	}
+	return cfg, nil
+}
+
+// getDuration reads a positive Go duration (e.g. "15m") from the environment, falling back when unset.
+// Whole days may also be written with a "d" suffix, such as "30d".
+func getDuration(key string, fallback time.Duration) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n > 0 {
+			return time.Duration(n) * 24 * time.Hour, nil
+		}
+	}
+
+	parsed, err := time.ParseDuration(value)
+	if err != nil || parsed <= 0 {
+		return 0, fmt.Errorf("%s must be a positive duration such as 15m, 24h or 30d", key)
+	}
+	return parsed, nil
+}
+
+// getPositiveInt reads a positive integer from the environment, falling back when unset
+func getPositiveInt(key string, fallback int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed <= 0 {
+		return 0, fmt.Errorf("%s must be a positive integer", key)
+	}
+	return parsed, nil
+}
+
+// getVerifiedActions reads a comma-separated list of actions that require a verified email.
+// Unset means every action does; "none" lets unverified users do everything. Accounts that
+// predate email verification were marked verified, so gating by default only affects new ones.
+func getVerifiedActions(key string) ([]string, error) {
+	value := os.Getenv(key)
+	switch value {
+	case "":
+		return []string{VerifiedActionPostJobs, VerifiedActionMessaging}, nil
+	case "none":
+		return nil, nil
+	}
+
+	var actions []string
+	for _, action := range strings.Split(value, ",") {
+		action = strings.TrimSpace(action)
+		if action != VerifiedActionPostJobs && action != VerifiedActionMessaging {
+			return nil, fmt.Errorf("%s must be \"none\" or a list of %q and %q", key, VerifiedActionPostJobs, VerifiedActionMessaging)
+		}
+		actions = append(actions, action)
+	}
+	return actions, nil
+}
+
+// getOIDCProviders reads the comma-separated names of the OpenID Connect providers and, for each,
+// OIDC_<NAME>_ISSUER, OIDC_<NAME>_CLIENT_ID, OIDC_<NAME>_CLIENT_SECRET and OIDC_<NAME>_REDIRECT_URL.
+// The redirect URL defaults to the frontend's /oidc/callback/<name> page.
+func getOIDCProviders(key, frontendURL string) ([]OIDCProviderConfig, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return nil, nil
+	}
+
+	var providers []OIDCProviderConfig
+	for _, name := range strings.Split(value, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || strings.Trim(name, "abcdefghijklmnopqrstuvwxyz0123456789_") != "" {
+			return nil, fmt.Errorf("%s must list provider names made of letters, digits and underscores", key)
+		}
+
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		provider := OIDCProviderConfig{
+			Name:         name,
+			Issuer:       os.Getenv(prefix + "ISSUER"),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			RedirectURL:  os.Getenv(prefix + "REDIRECT_URL"),
+		}
+		if provider.Issuer == "" || provider.ClientID == "" {
+			return nil, fmt.Errorf("%sISSUER and %sCLIENT_ID are required for OIDC provider %q", prefix, prefix, name)
+		}
+		if provider.RedirectURL == "" {
+			provider.RedirectURL = frontendURL + "/oidc/callback/" + name
+		}
+		providers = append(providers, provider)
+	}
+	return providers, nil
+}
+
+// RequiresVerifiedEmail reports whether users must verify their email before taking the action
+func (c *Config) RequiresVerifiedEmail(action string) bool {
+	return slices.Contains(c.VerifiedEmailRequiredFor, action)
 }
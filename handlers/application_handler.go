@@ -2,11 +2,15 @@ package handlers
 
 import (
 	"database/sql"
+	"errors"
+	"log"
 	"net/http"
 	"strconv"
 
+	"github.com/XORbit01/jobseeker-backend/config"
 	"github.com/XORbit01/jobseeker-backend/middleware"
 	"github.com/XORbit01/jobseeker-backend/models"
+	"github.com/XORbit01/jobseeker-backend/realtime"
 	"github.com/XORbit01/jobseeker-backend/repos"
 	"github.com/gin-gonic/gin"
 )
@@ -17,21 +21,27 @@ type ApplicationHandler struct {
 	jobSeekerRepo   *repos.JobSeekerRepository
 	employerRepo    *repos.EmployerRepository
 	jobRepo         *repos.JobRepository
+	chatRepo        *repos.ChatRepository
+	hub             *realtime.Hub
+	contactPolicy   string
 }
 
 // NewApplicationHandler creates a new ApplicationHandler
-func NewApplicationHandler(db *sql.DB) *ApplicationHandler {
+func NewApplicationHandler(db *sql.DB, hub *realtime.Hub, cfg *config.Config) *ApplicationHandler {
 	return &ApplicationHandler{
 		applicationRepo: repos.NewApplicationRepository(db),
 		jobSeekerRepo:   repos.NewJobSeekerRepository(db),
 		employerRepo:    repos.NewEmployerRepository(db),
 		jobRepo:         repos.NewJobRepository(db),
+		chatRepo:        repos.NewChatRepository(db),
+		hub:             hub,
+		contactPolicy:   cfg.ContactPolicy,
 	}
 }
 
 // RegisterApplicationRoutes registers application routes
-func RegisterApplicationRoutes(router *gin.RouterGroup, db *sql.DB) {
-	handler := NewApplicationHandler(db)
+func RegisterApplicationRoutes(router *gin.RouterGroup, db *sql.DB, hub *realtime.Hub, cfg *config.Config) {
+	handler := NewApplicationHandler(db, hub, cfg)
 
 	// Job seeker routes
 	jobSeekerGroup := router.Group("/")
@@ -52,6 +62,7 @@ func RegisterApplicationRoutes(router *gin.RouterGroup, db *sql.DB) {
 
 	// Common routes (accessible by both roles)
 	router.GET("/:id", handler.GetApplication)
+	router.POST("/:id/conversation", handler.OpenApplicationConversation)
 }
 
 // CreateApplication godoc
@@ -327,7 +338,7 @@ func (h *ApplicationHandler) GetJobApplications(c *gin.Context) {
 // UpdateApplicationStatus godoc
 //
 //	@Summary		Update application status
-//	@Description	Allows employers to update the status of applications for their own jobs.
+//	@Description	Allows employers to update the status of applications for their own jobs. The candidate is notified with an `application.status` event.
 //	@Tags			Applications
 //	@Security		BearerAuth
 //	@Accept			json
@@ -391,9 +402,31 @@ func (h *ApplicationHandler) UpdateApplicationStatus(c *gin.Context) {
 		return
 	}
 
+	h.publishStatusChange(application, userID.(int))
+
 	c.JSON(http.StatusOK, application)
 }
 
+// publishStatusChange tells the candidate, and the employer's other sessions, that an application changed status
+func (h *ApplicationHandler) publishStatusChange(application *models.Application, employerUserID int) {
+	_, jobSeekerUserID, _, err := h.applicationRepo.GetParties(application.ID)
+	if err != nil {
+		log.Printf("publishStatusChange: %v", err)
+		return
+	}
+
+	h.hub.SendToUsers([]int{jobSeekerUserID, employerUserID}, realtime.Event{
+		Type: realtime.EventApplicationStatus,
+		Data: models.ApplicationStatusUpdate{
+			ApplicationID: application.ID,
+			JobID:         application.JobID,
+			JobTitle:      application.JobTitle,
+			Status:        application.Status,
+			UpdatedAt:     application.UpdatedAt,
+		},
+	})
+}
+
 // DeleteApplication godoc
 //
 //	@Summary		Delete an application
@@ -448,3 +481,107 @@ func (h *ApplicationHandler) DeleteApplication(c *gin.Context) {
 
 	c.JSON(http.StatusOK, models.SuccessResponse{Success: true, Message: "application deleted successfully"})
 }
+
+// OpenApplicationConversation godoc
+//
+//	@Summary		Open the chat thread of an application
+//	@Description	Returns the conversation between the candidate and the employer attached to this application, creating it if needed. Messages are sent with POST /chats/conversations/{conversation_id}/messages. Under the restricted contact policy only the employer can open a new thread.
+//	@Tags			Applications
+//	@Security		BearerAuth
+//	@Produce		json
+//	@Param			id	path		int	true	"Application ID"
+//	@Success		200	{object}	models.SuccessResponse{data=models.Conversation}
+//	@Success		201	{object}	models.SuccessResponse{data=models.Conversation}
+//	@Failure		400	{object}	models.ErrorResponse
+//	@Failure		401	{object}	models.ErrorResponse
+//	@Failure		403	{object}	models.ErrorResponse
+//	@Failure		404	{object}	models.ErrorResponse
+//	@Failure		500	{object}	models.ErrorResponse
+//	@Router			/applications/{id}/conversation [post]
+func (h *ApplicationHandler) OpenApplicationConversation(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	applicationID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Message: "Invalid application ID",
+			Error:   &models.ErrorInfo{Code: "INVALID_INPUT"},
+		})
+		return
+	}
+
+	jobID, jobSeekerUserID, employerUserID, err := h.applicationRepo.GetParties(applicationID)
+	if errors.Is(err, repos.ErrApplicationNotFound) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Success: false,
+			Message: "Application not found",
+			Error:   &models.ErrorInfo{Code: "APPLICATION_NOT_FOUND"},
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Message: "Failed to fetch application",
+			Error:   &models.ErrorInfo{Code: "DB_ERROR", Details: err.Error()},
+		})
+		return
+	}
+	if userID != jobSeekerUserID && userID != employerUserID {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Success: false,
+			Message: "You don't have permission to access this application",
+			Error:   &models.ErrorInfo{Code: "FORBIDDEN"},
+		})
+		return
+	}
+
+	conv, err := h.chatRepo.GetConversationByApplication(applicationID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Message: "Failed to fetch conversation",
+			Error:   &models.ErrorInfo{Code: "DB_ERROR", Details: err.Error()},
+		})
+		return
+	}
+	if conv != nil {
+		c.JSON(http.StatusOK, models.SuccessResponse{
+			Success: true,
+			Message: "Conversation retrieved",
+			Data:    conv,
+		})
+		return
+	}
+
+	// Candidates may reply but not start a thread themselves
+	if userID == jobSeekerUserID && h.contactPolicy != config.ContactPolicyOpen {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Success: false,
+			Message: "The employer has not started a conversation about this application yet",
+			Error:   &models.ErrorInfo{Code: "FORBIDDEN_CONTACT"},
+		})
+		return
+	}
+
+	conv, created, err := h.chatRepo.GetOrCreateApplicationConversation(applicationID, jobID, userID, []int{jobSeekerUserID, employerUserID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Message: "Failed to open conversation",
+			Error:   &models.ErrorInfo{Code: "DB_ERROR", Details: err.Error()},
+		})
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	c.JSON(status, models.SuccessResponse{
+		Success: true,
+		Message: "Conversation opened",
+		Data:    conv,
+	})
+}
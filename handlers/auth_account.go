@@ -0,0 +1,279 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/XORbit01/jobseeker-backend/mailer"
+	"github.com/XORbit01/jobseeker-backend/models"
+	"github.com/XORbit01/jobseeker-backend/repos"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ChangePassword godoc
+//
+//	@Summary		Change the password
+//	@Description	Replaces the current user's password after checking the current one. Every session is logged out, including this one, and new tokens for this device are returned. Wrong passwords count towards the login lockout. Accounts created through a login provider have no password until they set one with a password reset.
+//	@Tags			auth
+//	@Security		BearerAuth
+//	@Accept			json
+//	@Produce		json
+//	@Param			input	body		models.ChangePasswordInput	true	"Current and new password"
+//	@Success		200		{object}	models.SuccessResponse{data=models.TokenResponse}
+//	@Failure		400		{object}	models.ErrorResponse
+//	@Failure		401		{object}	models.ErrorResponse
+//	@Failure		429		{object}	models.ErrorResponse
+//	@Failure		500		{object}	models.ErrorResponse
+//	@Router			/auth/change-password [post]
+func (h *AuthHandler) ChangePassword(c *gin.Context) {
+	var input models.ChangePasswordInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Message: "Invalid input",
+			Error:   &models.ErrorInfo{Code: "INVALID_PAYLOAD", Details: err.Error()},
+		})
+		return
+	}
+
+	user, ok := h.userWithPassword(c, input.CurrentPassword)
+	if !ok {
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Message: "Error hashing password",
+			Error:   &models.ErrorInfo{Code: "HASH_ERROR"},
+		})
+		return
+	}
+
+	if err := h.userRepo.UpdatePassword(user.ID, string(hashedPassword)); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Message: "Failed to change password",
+			Error:   &models.ErrorInfo{Code: "DB_ERROR", Details: err.Error()},
+		})
+		return
+	}
+	h.hub.DisconnectSessions(user.ID)
+
+	go h.sendAccountNotice(user.Email, "Your password was changed",
+		"The password of your account was changed on "+time.Now().UTC().Format(time.RFC1123)+
+			" and every device was logged out.\n\n"+
+			"If you did not do this, reset your password right away using \"Forgot password\" on the login page.")
+
+	h.writeNewSession(c, user, "Password changed. Other sessions have been logged out")
+}
+
+// ChangeEmail godoc
+//
+//	@Summary		Change the email address
+//	@Description	Starts changing the current user's email after checking their password. A confirmation link is sent to the new address and the old address is told about the request. The email only changes once the link is opened with /auth/confirm-email; until then it is shown as `pending_email`. Asking again replaces the pending address. Wrong passwords count towards the login lockout.
+//	@Tags			auth
+//	@Security		BearerAuth
+//	@Accept			json
+//	@Produce		json
+//	@Param			input	body		models.ChangeEmailInput	true	"New email and password"
+//	@Success		200		{object}	models.SuccessResponse
+//	@Failure		400		{object}	models.ErrorResponse
+//	@Failure		401		{object}	models.ErrorResponse
+//	@Failure		429		{object}	models.ErrorResponse
+//	@Failure		500		{object}	models.ErrorResponse
+//	@Router			/auth/change-email [post]
+func (h *AuthHandler) ChangeEmail(c *gin.Context) {
+	var input models.ChangeEmailInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Message: "Invalid input",
+			Error:   &models.ErrorInfo{Code: "INVALID_PAYLOAD", Details: err.Error()},
+		})
+		return
+	}
+
+	user, ok := h.userWithPassword(c, input.Password)
+	if !ok {
+		return
+	}
+
+	if strings.EqualFold(input.NewEmail, user.Email) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Message: "This is already your email address",
+			Error:   &models.ErrorInfo{Code: "SAME_EMAIL"},
+		})
+		return
+	}
+
+	err := h.userRepo.SetPendingEmail(user.ID, input.NewEmail)
+	if errors.Is(err, repos.ErrEmailTaken) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Message: "Email already in use",
+			Error:   &models.ErrorInfo{Code: "EMAIL_EXISTS"},
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Message: "Failed to change email",
+			Error:   &models.ErrorInfo{Code: "DB_ERROR", Details: err.Error()},
+		})
+		return
+	}
+
+	go h.sendAccountNotice(user.Email, "Your email address is being changed",
+		"A change of the email address of your account to "+input.NewEmail+" was requested on "+
+			time.Now().UTC().Format(time.RFC1123)+". It takes effect once the link sent to the new address is opened.\n\n"+
+			"If you did not do this, change your password right away.")
+	go func() {
+		if err := h.sendEmailChangeConfirmation(user.ID, input.NewEmail); err != nil {
+			log.Printf("sendEmailChangeConfirmation: %v", err)
+		}
+	}()
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Success: true,
+		Message: "We sent a confirmation link to your new address",
+	})
+}
+
+// ConfirmEmail godoc
+//
+//	@Summary		Confirm an email change
+//	@Description	Makes the pending address the user's email, verified, using the token from the link sent to it. Links expire after 48 hours and stop working if another address is requested. Every session is logged out, so the user logs in again with the new address.
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			input	body		models.VerifyEmailInput	true	"Confirmation token"
+//	@Success		200		{object}	models.SuccessResponse
+//	@Failure		400		{object}	models.ErrorResponse
+//	@Failure		500		{object}	models.ErrorResponse
+//	@Router			/auth/confirm-email [post]
+func (h *AuthHandler) ConfirmEmail(c *gin.Context) {
+	var input models.VerifyEmailInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Message: "Invalid input",
+			Error:   &models.ErrorInfo{Code: "INVALID_PAYLOAD", Details: err.Error()},
+		})
+		return
+	}
+
+	user, ok := h.userFromSignedToken(purposeEmailChange, input.Token)
+	err := repos.ErrPendingEmailChanged
+	if ok {
+		err = h.userRepo.ConfirmEmail(user.ID, user.PendingEmail)
+	}
+	if errors.Is(err, repos.ErrPendingEmailChanged) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Message: "This confirmation link is invalid or has expired",
+			Error:   &models.ErrorInfo{Code: "INVALID_CONFIRMATION_TOKEN"},
+		})
+		return
+	}
+	if errors.Is(err, repos.ErrEmailTaken) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Message: "Email already in use",
+			Error:   &models.ErrorInfo{Code: "EMAIL_EXISTS"},
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Message: "Failed to change email",
+			Error:   &models.ErrorInfo{Code: "DB_ERROR", Details: err.Error()},
+		})
+		return
+	}
+	h.hub.DisconnectSessions(user.ID)
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Success: true,
+		Message: "Email changed. Please log in with your new address",
+	})
+}
+
+// sendEmailChangeConfirmation emails the new address a signed link that completes the change
+func (h *AuthHandler) sendEmailChangeConfirmation(userID int, email string) error {
+	token := h.signedUserToken(purposeEmailChange, userID, email, time.Now().Add(emailVerificationTTL))
+	link := fmt.Sprintf("%s/confirm-email?token=%s", h.frontendURL, url.QueryEscape(token))
+	return h.mailer.Send(mailer.Message{
+		To:      email,
+		Subject: "Confirm your new email address",
+		Body: "Please confirm that this is the new email address of your account by opening this link within 48 hours:\n" +
+			link + "\n\n" +
+			"If you did not ask for this, you can ignore this email.",
+	})
+}
+
+// userWithPassword returns the current user if the password is theirs. Wrong passwords count as
+// failed logins, so a stolen token cannot be used to guess the password. It writes the response
+// when the password is not accepted.
+func (h *AuthHandler) userWithPassword(c *gin.Context, password string) (*models.User, bool) {
+	user, err := h.userRepo.GetByID(c.GetInt("userID"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Success: false,
+			Message: "User not found",
+			Error:   &models.ErrorInfo{Code: "USER_NOT_FOUND"},
+		})
+		return nil, false
+	}
+
+	if h.loginLocked(c, user.Email) {
+		return nil, false
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		h.recordLoginFailure(c, user.Email, user)
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Success: false,
+			Message: "Invalid password",
+			Error:   &models.ErrorInfo{Code: "AUTH_FAILED"},
+		})
+		return nil, false
+	}
+	return user, true
+}
+
+// writeNewSession logs the user in again on this device after their other sessions were revoked
+func (h *AuthHandler) writeNewSession(c *gin.Context, user *models.User, message string) {
+	tokens, err := h.startSession(c, user.ID, user.Role)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Message: "Error generating token",
+			Error:   &models.ErrorInfo{Code: "JWT_ERROR", Details: err.Error()},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Success: true,
+		Message: message,
+		Data:    tokens,
+	})
+}
+
+// sendAccountNotice tells the owner of an address about a change to their account
+func (h *AuthHandler) sendAccountNotice(email, subject, body string) {
+	if err := h.mailer.Send(mailer.Message{To: email, Subject: subject, Body: body}); err != nil {
+		log.Printf("sendAccountNotice: %v", err)
+	}
+}
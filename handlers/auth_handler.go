@@ -3,36 +3,102 @@ package handlers
 import (
 	"database/sql"
 	"net/http"
+	"time"
 
+	"github.com/XORbit01/jobseeker-backend/config"
+	"github.com/XORbit01/jobseeker-backend/mailer"
 	"github.com/XORbit01/jobseeker-backend/middleware"
 	"github.com/XORbit01/jobseeker-backend/models"
+	"github.com/XORbit01/jobseeker-backend/oidc"
+	"github.com/XORbit01/jobseeker-backend/realtime"
 	"github.com/XORbit01/jobseeker-backend/repos"
 	"github.com/gin-gonic/gin"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type AuthHandler struct {
-	userRepo      *repos.UserRepository
-	tokenLifetime string
+	userRepo             *repos.UserRepository
+	sessionRepo          *repos.SessionRepository
+	resetRepo            *repos.PasswordResetRepository
+	twoFactorRepo        *repos.TwoFactorRepository
+	throttleRepo         *repos.LoginThrottleRepository
+	identityRepo         *repos.IdentityRepository
+	mailer               mailer.Mailer
+	hub                  *realtime.Hub
+	tokenKeys            *middleware.TokenKeys
+	tokenLifetime        time.Duration
+	refreshTokenLifetime time.Duration
+	frontendURL          string
+	signingKey           []byte
+	// Login throttling, see config.Config
+	maxLoginAttempts      int
+	maxLoginAttemptsPerIP int
+	loginLockout          time.Duration
+	// OpenID Connect providers by name
+	oidcProviders map[string]*oidc.Provider
 }
 
-func NewAuthHandler(db *sql.DB, tokenLifetime string) *AuthHandler {
+func NewAuthHandler(db *sql.DB, cfg *config.Config, mail mailer.Mailer, keys *middleware.TokenKeys, hub *realtime.Hub) *AuthHandler {
+	oidcProviders := make(map[string]*oidc.Provider, len(cfg.OIDCProviders))
+	for _, provider := range cfg.OIDCProviders {
+		oidcProviders[provider.Name] = oidc.NewProvider(provider)
+	}
+
 	return &AuthHandler{
-		userRepo:      repos.NewUserRepository(db),
-		tokenLifetime: tokenLifetime,
+		userRepo:              repos.NewUserRepository(db),
+		sessionRepo:           repos.NewSessionRepository(db),
+		resetRepo:             repos.NewPasswordResetRepository(db),
+		twoFactorRepo:         repos.NewTwoFactorRepository(db),
+		throttleRepo:          repos.NewLoginThrottleRepository(db),
+		identityRepo:          repos.NewIdentityRepository(db),
+		mailer:                mail,
+		hub:                   hub,
+		tokenKeys:             keys,
+		tokenLifetime:         cfg.TokenLifetime,
+		refreshTokenLifetime:  cfg.RefreshTokenLifetime,
+		frontendURL:           cfg.FrontendURL,
+		signingKey:            []byte(cfg.JWTSecret),
+		maxLoginAttempts:      cfg.LoginMaxAttempts,
+		maxLoginAttemptsPerIP: cfg.LoginMaxAttemptsPerIP,
+		loginLockout:          cfg.LoginLockout,
+		oidcProviders:         oidcProviders,
 	}
 }
 
-func RegisterAuthRoutes(router *gin.RouterGroup, db *sql.DB) {
-	tokenLifetime := "24h"
-	handler := NewAuthHandler(db, tokenLifetime)
+func RegisterAuthRoutes(router *gin.RouterGroup, db *sql.DB, cfg *config.Config, mail mailer.Mailer, keys *middleware.TokenKeys, hub *realtime.Hub) {
+	handler := NewAuthHandler(db, cfg, mail, keys, hub)
 
 	router.POST("/register", handler.Register)
 	router.POST("/login", handler.Login)
+	router.POST("/refresh", handler.Refresh)
+	router.POST("/forgot-password", handler.ForgotPassword)
+	router.POST("/reset-password", handler.ResetPassword)
+	router.POST("/verify-email", handler.VerifyEmail)
+	router.POST("/confirm-email", handler.ConfirmEmail)
+	router.POST("/2fa/verify", handler.VerifyTwoFactor)
+	router.POST("/unlock", handler.UnlockAccount)
+	router.GET("/oidc/providers", handler.ListOIDCProviders)
+	router.GET("/oidc/:provider/authorize", handler.StartOIDCLogin)
+	router.POST("/oidc/:provider/callback", handler.OIDCCallback)
+
+	authenticated := router.Group("", middleware.AuthMiddleware(handler.tokenKeys, handler.sessionRepo))
+	authenticated.POST("/logout", handler.Logout)
+	authenticated.POST("/logout-all", handler.LogoutAll)
+	authenticated.POST("/resend-verification", handler.ResendVerification)
+	authenticated.POST("/change-password", handler.ChangePassword)
+	authenticated.POST("/change-email", handler.ChangeEmail)
+	// Linking is refused for unverified addresses, whatever the verification policy, so that
+	// someone squatting an address cannot keep a way into the account once its owner takes it over
+	authenticated.GET("/oidc/:provider/link", middleware.VerifiedEmailMiddleware(handler.userRepo, true), handler.StartOIDCLink)
+	authenticated.POST("/2fa/setup", handler.SetupTwoFactor)
+	authenticated.POST("/2fa/confirm", handler.ConfirmTwoFactor)
+	authenticated.POST("/2fa/disable", handler.DisableTwoFactor)
 }
 
+// Register godoc
+//
 //	@Summary		Register a new user
-//	@Description	Create a new user account and return a JWT token
+//	@Description	Create a new user account and return an access token with a refresh token. A verification link is emailed to the address.
 //	@Tags			auth
 //	@Accept			json
 //	@Produce		json
@@ -81,12 +147,14 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
-	token, err := middleware.GenerateToken(userID, input.Role, h.tokenLifetime)
+	h.sendVerificationEmailAsync(userID, input.Email)
+
+	tokens, err := h.startSession(c, userID, input.Role)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Success: false,
 			Message: "Error generating token",
-			Error:   &models.ErrorInfo{Code: "JWT_ERROR"},
+			Error:   &models.ErrorInfo{Code: "JWT_ERROR", Details: err.Error()},
 		})
 		return
 	}
@@ -94,15 +162,14 @@ func (h *AuthHandler) Register(c *gin.Context) {
 	c.JSON(http.StatusCreated, models.SuccessResponse{
 		Success: true,
 		Message: "User registered successfully",
-		Data: models.TokenResponse{
-			Token: token,
-			Role:  input.Role,
-		},
+		Data:    tokens,
 	})
 }
 
+// Login godoc
+//
 //	@Summary		Login an existing user
-//	@Description	Authenticate user and return JWT token
+//	@Description	Authenticate user and return an access token with a refresh token. Accounts with two-factor authentication get a `models.TwoFactorChallenge` instead, to be completed at /auth/2fa/verify.
 //	@Tags			auth
 //	@Accept			json
 //	@Produce		json
@@ -110,6 +177,7 @@ func (h *AuthHandler) Register(c *gin.Context) {
 //	@Success		200		{object}	models.SuccessResponse{data=models.TokenResponse}
 //	@Failure		400		{object}	models.ErrorResponse
 //	@Failure		401		{object}	models.ErrorResponse
+//	@Failure		429		{object}	models.ErrorResponse
 //	@Router			/auth/login [post]
 func (h *AuthHandler) Login(c *gin.Context) {
 	var input models.LoginInput
@@ -122,8 +190,13 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
+	if h.loginLocked(c, input.Email) {
+		return
+	}
+
 	user, err := h.userRepo.GetByEmail(input.Email)
 	if err != nil {
+		h.recordLoginFailure(c, input.Email, nil)
 		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
 			Success: false,
 			Message: "Invalid email or password",
@@ -133,6 +206,7 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(input.Password)); err != nil {
+		h.recordLoginFailure(c, input.Email, user)
 		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
 			Success: false,
 			Message: "Invalid email or password",
@@ -141,12 +215,18 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	token, err := middleware.GenerateToken(user.ID, user.Role, h.tokenLifetime)
+	// With two-factor authentication, failures are only forgotten once the code is accepted
+	if h.challengeTwoFactor(c, user) {
+		return
+	}
+	h.resetLoginFailures(input.Email)
+
+	tokens, err := h.startSession(c, user.ID, user.Role)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Success: false,
 			Message: "Error generating token",
-			Error:   &models.ErrorInfo{Code: "JWT_ERROR"},
+			Error:   &models.ErrorInfo{Code: "JWT_ERROR", Details: err.Error()},
 		})
 		return
 	}
@@ -154,9 +234,6 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	c.JSON(http.StatusOK, models.SuccessResponse{
 		Success: true,
 		Message: "Login successful",
-		Data: models.TokenResponse{
-			Token: token,
-			Role:  user.Role,
-		},
+		Data:    tokens,
 	})
 }
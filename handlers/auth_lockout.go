@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/XORbit01/jobseeker-backend/mailer"
+	"github.com/XORbit01/jobseeker-backend/models"
+	"github.com/XORbit01/jobseeker-backend/repos"
+	"github.com/gin-gonic/gin"
+)
+
+// accountUnlockTTL is how long the link in a lockout email stays valid
+const accountUnlockTTL = 24 * time.Hour
+
+// UnlockAccount godoc
+//
+//	@Summary		Unlock an account
+//	@Description	Lifts a login lockout using the link emailed when the account was locked after too many failed attempts
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			input	body		models.UnlockAccountInput	true	"Unlock token"
+//	@Success		200		{object}	models.SuccessResponse
+//	@Failure		400		{object}	models.ErrorResponse
+//	@Failure		500		{object}	models.ErrorResponse
+//	@Router			/auth/unlock [post]
+func (h *AuthHandler) UnlockAccount(c *gin.Context) {
+	var input models.UnlockAccountInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Message: "Invalid input",
+			Error:   &models.ErrorInfo{Code: "INVALID_PAYLOAD", Details: err.Error()},
+		})
+		return
+	}
+
+	user, ok := h.userFromSignedToken(purposeAccountUnlock, input.Token)
+	if !ok {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Message: "This unlock link is invalid or has expired",
+			Error:   &models.ErrorInfo{Code: "INVALID_UNLOCK_TOKEN"},
+		})
+		return
+	}
+
+	if err := h.throttleRepo.Reset(repos.EmailKey(user.Email)); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Message: "Failed to unlock account",
+			Error:   &models.ErrorInfo{Code: "DB_ERROR", Details: err.Error()},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Success: true,
+		Message: "Account unlocked. You can log in again",
+	})
+}
+
+// loginLocked writes a 429 response when the account or the client IP is locked out
+func (h *AuthHandler) loginLocked(c *gin.Context, email string) bool {
+	lockedUntil, err := h.throttleRepo.LockedUntil(repos.EmailKey(email), repos.IPKey(c.ClientIP()))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Message: "Failed to check login attempts",
+			Error:   &models.ErrorInfo{Code: "DB_ERROR", Details: err.Error()},
+		})
+		return true
+	}
+	if lockedUntil.IsZero() {
+		return false
+	}
+
+	retryAfter := int(math.Ceil(time.Until(lockedUntil).Seconds()))
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	c.JSON(http.StatusTooManyRequests, models.ErrorResponse{
+		Success: false,
+		Message: "Too many failed login attempts. Try again later",
+		Error:   &models.ErrorInfo{Code: "ACCOUNT_LOCKED", RetryAfter: retryAfter},
+	})
+	return true
+}
+
+// recordLoginFailure counts a failed attempt for the email and the client IP. When this locks an
+// existing account, its owner is emailed a link to unlock it.
+func (h *AuthHandler) recordLoginFailure(c *gin.Context, email string, user *models.User) {
+	if _, err := h.throttleRepo.RecordFailure(repos.IPKey(c.ClientIP()), h.maxLoginAttemptsPerIP, h.loginLockout); err != nil {
+		log.Printf("recordLoginFailure: %v", err)
+	}
+
+	lockedUntil, err := h.throttleRepo.RecordFailure(repos.EmailKey(email), h.maxLoginAttempts, h.loginLockout)
+	if err != nil {
+		log.Printf("recordLoginFailure: %v", err)
+		return
+	}
+	if user != nil && !lockedUntil.IsZero() {
+		go h.sendUnlockEmail(user, lockedUntil)
+	}
+}
+
+// resetLoginFailures forgets the failed attempts of an account after a successful login
+func (h *AuthHandler) resetLoginFailures(email string) {
+	if err := h.throttleRepo.Reset(repos.EmailKey(email)); err != nil {
+		log.Printf("resetLoginFailures: %v", err)
+	}
+}
+
+func (h *AuthHandler) sendUnlockEmail(user *models.User, lockedUntil time.Time) {
+	token := h.signedUserToken(purposeAccountUnlock, user.ID, user.Email, time.Now().Add(accountUnlockTTL))
+	link := fmt.Sprintf("%s/unlock-account?token=%s", h.frontendURL, url.QueryEscape(token))
+	err := h.mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Your account has been locked",
+		Body: "There were too many failed attempts to log in to your account, so logins are paused until " +
+			lockedUntil.UTC().Format(time.RFC1123) + ".\n\n" +
+			"If this was you, open this link to unlock your account now:\n" +
+			link + "\n\n" +
+			"If it was not you, someone may be trying to guess your password. Consider changing it and " +
+			"enabling two-factor authentication.",
+	})
+	if err != nil {
+		log.Printf("sendUnlockEmail: %v", err)
+	}
+}
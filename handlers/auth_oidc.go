@@ -0,0 +1,341 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/XORbit01/jobseeker-backend/models"
+	"github.com/XORbit01/jobseeker-backend/oidc"
+	"github.com/XORbit01/jobseeker-backend/repos"
+	"github.com/gin-gonic/gin"
+)
+
+// oidcLoginTTL is how long a user has to log in at the provider
+const oidcLoginTTL = 10 * time.Minute
+
+// ListOIDCProviders godoc
+//
+//	@Summary		List login providers
+//	@Description	Returns the names of the OpenID Connect providers users can log in with
+//	@Tags			auth
+//	@Produce		json
+//	@Success		200	{object}	models.SuccessResponse
+//	@Router			/auth/oidc/providers [get]
+func (h *AuthHandler) ListOIDCProviders(c *gin.Context) {
+	names := make([]string, 0, len(h.oidcProviders))
+	for name := range h.oidcProviders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Success: true,
+		Message: "Login providers retrieved",
+		Data:    gin.H{"providers": names},
+	})
+}
+
+// StartOIDCLogin godoc
+//
+//	@Summary		Start a login with a provider
+//	@Description	Returns the provider URL to send the user to. The provider sends them back to the configured redirect URL with a code and the state, which are then posted to /auth/oidc/{provider}/callback. The role is given to the account if the login creates one.
+//	@Tags			auth
+//	@Produce		json
+//	@Param			provider	path		string	true	"Provider name"
+//	@Param			role		query		string	false	"Role of a new account"	Enums(job_seeker, employer)	default(job_seeker)
+//	@Success		200			{object}	models.SuccessResponse{data=models.OIDCAuthorization}
+//	@Failure		400			{object}	models.ErrorResponse
+//	@Failure		404			{object}	models.ErrorResponse
+//	@Failure		502			{object}	models.ErrorResponse
+//	@Router			/auth/oidc/{provider}/authorize [get]
+func (h *AuthHandler) StartOIDCLogin(c *gin.Context) {
+	provider, ok := h.oidcProvider(c)
+	if !ok {
+		return
+	}
+
+	role := c.DefaultQuery("role", "job_seeker")
+	if role != "job_seeker" && role != "employer" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Message: "Role must be job_seeker or employer",
+			Error:   &models.ErrorInfo{Code: "INVALID_ROLE"},
+		})
+		return
+	}
+
+	h.startOIDC(c, provider, repos.OIDCLoginState{Role: role})
+}
+
+// StartOIDCLink godoc
+//
+//	@Summary		Link a provider to the current account
+//	@Description	Like /auth/oidc/{provider}/authorize, but the callback links the provider account to the current user instead of logging in. Only accounts with a verified email can link a provider (403 EMAIL_NOT_VERIFIED). Accounts created before email verification existed have to be linked this way before they can log in with a provider.
+//	@Tags			auth
+//	@Security		BearerAuth
+//	@Produce		json
+//	@Param			provider	path		string	true	"Provider name"
+//	@Success		200			{object}	models.SuccessResponse{data=models.OIDCAuthorization}
+//	@Failure		401			{object}	models.ErrorResponse
+//	@Failure		403			{object}	models.ErrorResponse
+//	@Failure		404			{object}	models.ErrorResponse
+//	@Failure		502			{object}	models.ErrorResponse
+//	@Router			/auth/oidc/{provider}/link [get]
+func (h *AuthHandler) StartOIDCLink(c *gin.Context) {
+	provider, ok := h.oidcProvider(c)
+	if !ok {
+		return
+	}
+
+	h.startOIDC(c, provider, repos.OIDCLoginState{Role: "job_seeker", LinkUserID: c.GetInt("userID")})
+}
+
+// startOIDC remembers a login or link with fresh PKCE and nonce values and returns the provider URL
+func (h *AuthHandler) startOIDC(c *gin.Context, provider *oidc.Provider, loginState repos.OIDCLoginState) {
+	var state string
+	var err error
+	for _, value := range []*string{&state, &loginState.Nonce, &loginState.CodeVerifier} {
+		if *value, err = newSecureToken(); err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Success: false,
+				Message: "Error generating token",
+				Error:   &models.ErrorInfo{Code: "JWT_ERROR", Details: err.Error()},
+			})
+			return
+		}
+	}
+
+	authURL, err := provider.AuthCodeURL(c.Request.Context(), state, loginState.Nonce, loginState.CodeVerifier)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, models.ErrorResponse{
+			Success: false,
+			Message: "The login provider is unavailable",
+			Error:   &models.ErrorInfo{Code: "OIDC_PROVIDER_UNAVAILABLE", Details: err.Error()},
+		})
+		return
+	}
+
+	expiresAt := time.Now().Add(oidcLoginTTL)
+	if err := h.identityRepo.SaveLoginState(hashToken(state), provider.Name(), loginState, expiresAt); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Message: "Failed to start login",
+			Error:   &models.ErrorInfo{Code: "DB_ERROR", Details: err.Error()},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Success: true,
+		Message: "Continue at the provider",
+		Data: models.OIDCAuthorization{
+			AuthorizationURL: authURL,
+			State:            state,
+			ExpiresAt:        expiresAt,
+		},
+	})
+}
+
+// OIDCCallback godoc
+//
+//	@Summary		Complete a login with a provider
+//	@Description	Redeems the code the provider sent the user back with and logs them in. The provider account is linked to the user with the same email if the provider verified it, and a new account is created otherwise. Accounts created before email verification existed are not linked automatically (409 OIDC_LINK_REQUIRED); their owners link the provider with /auth/oidc/{provider}/link. Accounts with two-factor authentication get a `models.TwoFactorChallenge` instead of tokens. For links started with /auth/oidc/{provider}/link, the response has no tokens.
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			provider	path		string						true	"Provider name"
+//	@Param			input		body		models.OIDCCallbackInput	true	"Code and state from the provider"
+//	@Success		200			{object}	models.SuccessResponse{data=models.TokenResponse}
+//	@Success		201			{object}	models.SuccessResponse{data=models.TokenResponse}
+//	@Failure		400			{object}	models.ErrorResponse
+//	@Failure		401			{object}	models.ErrorResponse
+//	@Failure		403			{object}	models.ErrorResponse
+//	@Failure		404			{object}	models.ErrorResponse
+//	@Failure		409			{object}	models.ErrorResponse
+//	@Failure		500			{object}	models.ErrorResponse
+//	@Router			/auth/oidc/{provider}/callback [post]
+func (h *AuthHandler) OIDCCallback(c *gin.Context) {
+	provider, ok := h.oidcProvider(c)
+	if !ok {
+		return
+	}
+
+	var input models.OIDCCallbackInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Message: "Invalid input",
+			Error:   &models.ErrorInfo{Code: "INVALID_PAYLOAD", Details: err.Error()},
+		})
+		return
+	}
+
+	state, err := h.identityRepo.ConsumeLoginState(hashToken(input.State), provider.Name())
+	if errors.Is(err, repos.ErrLoginStateInvalid) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Message: "This login is invalid or has expired; please start again",
+			Error:   &models.ErrorInfo{Code: "INVALID_OIDC_STATE"},
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Message: "Failed to complete login",
+			Error:   &models.ErrorInfo{Code: "DB_ERROR", Details: err.Error()},
+		})
+		return
+	}
+
+	identity, err := provider.Exchange(c.Request.Context(), input.Code, state.CodeVerifier, state.Nonce)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Success: false,
+			Message: "The login provider did not confirm your identity",
+			Error:   &models.ErrorInfo{Code: "OIDC_LOGIN_FAILED", Details: err.Error()},
+		})
+		return
+	}
+
+	if state.LinkUserID != 0 {
+		h.linkOIDCIdentity(c, provider.Name(), identity, state.LinkUserID)
+		return
+	}
+
+	user, created, err := h.userForIdentity(provider.Name(), identity, state.Role)
+	if errors.Is(err, repos.ErrLinkRequiresLogin) {
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Success: false,
+			Message: "An account with this email already exists. Log in with your password and link the provider from your account",
+			Error:   &models.ErrorInfo{Code: "OIDC_LINK_REQUIRED"},
+		})
+		return
+	}
+	if errors.Is(err, repos.ErrIdentityNotFound) {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Success: false,
+			Message: "The login provider has not verified your email address",
+			Error:   &models.ErrorInfo{Code: "OIDC_EMAIL_NOT_VERIFIED"},
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Message: "Failed to complete login",
+			Error:   &models.ErrorInfo{Code: "DB_ERROR", Details: err.Error()},
+		})
+		return
+	}
+
+	if h.challengeTwoFactor(c, user) {
+		return
+	}
+
+	tokens, err := h.startSession(c, user.ID, user.Role)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Message: "Error generating token",
+			Error:   &models.ErrorInfo{Code: "JWT_ERROR", Details: err.Error()},
+		})
+		return
+	}
+
+	status, message := http.StatusOK, "Login successful"
+	if created {
+		status, message = http.StatusCreated, "User registered successfully"
+	}
+	c.JSON(status, models.SuccessResponse{
+		Success: true,
+		Message: message,
+		Data:    tokens,
+	})
+}
+
+// userForIdentity returns the user linked to the provider account. An unlinked account is linked
+// to the user with the same email, or a user with the role is created for it, as long as the
+// provider verified the email. Otherwise it returns repos.ErrIdentityNotFound, or
+// repos.ErrLinkRequiresLogin when the user with the email has to link the provider themselves.
+func (h *AuthHandler) userForIdentity(provider string, identity *oidc.Identity, role string) (*models.User, bool, error) {
+	userID, err := h.identityRepo.FindUser(provider, identity.Subject)
+	if err == nil {
+		user, err := h.userRepo.GetByID(userID)
+		return user, false, err
+	}
+	if !errors.Is(err, repos.ErrIdentityNotFound) {
+		return nil, false, err
+	}
+	if identity.Email == "" || !identity.EmailVerified {
+		return nil, false, repos.ErrIdentityNotFound
+	}
+
+	if existing, err := h.userRepo.GetByEmail(identity.Email); err == nil {
+		if err := h.identityRepo.LinkByEmail(existing.ID, provider, identity.Subject, identity.Email); err != nil {
+			return nil, false, err
+		}
+		if existing.EmailVerifiedAt == nil {
+			// The sessions of whoever registered the address were revoked
+			h.hub.DisconnectSessions(existing.ID)
+		}
+		user, err := h.userRepo.GetByID(existing.ID)
+		return user, false, err
+	}
+
+	userID, err = h.identityRepo.CreateUser(identity.Email, role, provider, identity.Subject)
+	if err != nil {
+		return nil, false, err
+	}
+	user, err := h.userRepo.GetByID(userID)
+	return user, true, err
+}
+
+// linkOIDCIdentity completes a link started by a logged-in user with /auth/oidc/{provider}/link
+func (h *AuthHandler) linkOIDCIdentity(c *gin.Context, provider string, identity *oidc.Identity, userID int) {
+	err := h.identityRepo.Link(userID, provider, identity.Subject, identity.Email)
+	if errors.Is(err, repos.ErrEmailNotVerified) {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Success: false,
+			Message: "Please verify your email address first",
+			Error:   &models.ErrorInfo{Code: "EMAIL_NOT_VERIFIED"},
+		})
+		return
+	}
+	if errors.Is(err, repos.ErrIdentityInUse) {
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Success: false,
+			Message: "This provider account is already linked to an account",
+			Error:   &models.ErrorInfo{Code: "OIDC_IDENTITY_IN_USE"},
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Message: "Failed to link account",
+			Error:   &models.ErrorInfo{Code: "DB_ERROR", Details: err.Error()},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Success: true,
+		Message: "Login provider linked to your account",
+	})
+}
+
+// oidcProvider returns the provider named in the path, writing a 404 response when there is none
+func (h *AuthHandler) oidcProvider(c *gin.Context) (*oidc.Provider, bool) {
+	provider, ok := h.oidcProviders[c.Param("provider")]
+	if !ok {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Success: false,
+			Message: "Unknown login provider",
+			Error:   &models.ErrorInfo{Code: "OIDC_PROVIDER_NOT_FOUND"},
+		})
+	}
+	return provider, ok
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/XORbit01/jobseeker-backend/mailer"
+	"github.com/XORbit01/jobseeker-backend/models"
+	"github.com/XORbit01/jobseeker-backend/repos"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// passwordResetTTL is how long a password reset link stays valid
+const passwordResetTTL = time.Hour
+
+// ForgotPassword godoc
+//
+//	@Summary		Request a password reset
+//	@Description	Emails a single-use link to reset the password, valid for one hour. The response is the same whether or not an account exists for the email.
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			input	body		models.ForgotPasswordInput	true	"Account email"
+//	@Success		200		{object}	models.SuccessResponse
+//	@Failure		400		{object}	models.ErrorResponse
+//	@Router			/auth/forgot-password [post]
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
+	var input models.ForgotPasswordInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Message: "Invalid input",
+			Error:   &models.ErrorInfo{Code: "INVALID_PAYLOAD", Details: err.Error()},
+		})
+		return
+	}
+
+	// The email is sent in the background so that response times do not reveal which accounts exist
+	if user, err := h.userRepo.GetByEmail(input.Email); err == nil {
+		go h.sendPasswordReset(user)
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Success: true,
+		Message: "If an account exists for this email, a password reset link has been sent",
+	})
+}
+
+// ResetPassword godoc
+//
+//	@Summary		Reset the password
+//	@Description	Sets a new password using the token from a password reset email. The token can be used once, and every existing session of the account is logged out.
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			input	body		models.ResetPasswordInput	true	"Reset token and new password"
+//	@Success		200		{object}	models.SuccessResponse
+//	@Failure		400		{object}	models.ErrorResponse
+//	@Failure		500		{object}	models.ErrorResponse
+//	@Router			/auth/reset-password [post]
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var input models.ResetPasswordInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Message: "Invalid input",
+			Error:   &models.ErrorInfo{Code: "INVALID_PAYLOAD", Details: err.Error()},
+		})
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Message: "Error hashing password",
+			Error:   &models.ErrorInfo{Code: "HASH_ERROR"},
+		})
+		return
+	}
+
+	userID, err := h.resetRepo.Reset(hashToken(input.Token), string(hashedPassword))
+	if errors.Is(err, repos.ErrResetTokenInvalid) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Message: "This password reset link is invalid or has expired",
+			Error:   &models.ErrorInfo{Code: "INVALID_RESET_TOKEN"},
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Message: "Failed to reset password",
+			Error:   &models.ErrorInfo{Code: "DB_ERROR", Details: err.Error()},
+		})
+		return
+	}
+	h.hub.DisconnectSessions(userID)
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Success: true,
+		Message: "Password has been reset. Please log in with your new password",
+	})
+}
+
+// sendPasswordReset creates a reset token for the user and emails them the link
+func (h *AuthHandler) sendPasswordReset(user *models.User) {
+	token, err := newSecureToken()
+	if err != nil {
+		log.Printf("sendPasswordReset: %v", err)
+		return
+	}
+	if err := h.resetRepo.Create(user.ID, hashToken(token), time.Now().Add(passwordResetTTL)); err != nil {
+		log.Printf("sendPasswordReset: %v", err)
+		return
+	}
+
+	link := fmt.Sprintf("%s/reset-password?token=%s", h.frontendURL, url.QueryEscape(token))
+	err = h.mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: "We received a request to reset the password of your account.\n\n" +
+			"Open this link to choose a new password. It expires in one hour and can be used once:\n" +
+			link + "\n\n" +
+			"If you did not ask for this, you can ignore this email.",
+	})
+	if err != nil {
+		log.Printf("sendPasswordReset: %v", err)
+	}
+}
@@ -0,0 +1,269 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/XORbit01/jobseeker-backend/models"
+	"github.com/XORbit01/jobseeker-backend/repos"
+	"github.com/gin-gonic/gin"
+)
+
+// Refresh godoc
+//
+//	@Summary		Refresh the access token
+//	@Description	Exchanges a refresh token for a new access token and a new refresh token. Each refresh token can be used once; presenting one that was already exchanged revokes the whole session, so every token issued from the same login stops working.
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			input	body		models.RefreshTokenInput	true	"Refresh token"
+//	@Success		200		{object}	models.SuccessResponse{data=models.TokenResponse}
+//	@Failure		400		{object}	models.ErrorResponse
+//	@Failure		401		{object}	models.ErrorResponse
+//	@Failure		500		{object}	models.ErrorResponse
+//	@Router			/auth/refresh [post]
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var input models.RefreshTokenInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Message: "Invalid input",
+			Error:   &models.ErrorInfo{Code: "INVALID_PAYLOAD", Details: err.Error()},
+		})
+		return
+	}
+
+	refreshToken, err := newSecureToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Message: "Error generating token",
+			Error:   &models.ErrorInfo{Code: "JWT_ERROR", Details: err.Error()},
+		})
+		return
+	}
+
+	userID, sessionID, err := h.sessionRepo.Rotate(hashToken(input.RefreshToken), hashToken(refreshToken),
+		time.Now().Add(h.refreshTokenLifetime))
+	if err != nil {
+		writeRefreshError(c, err)
+		return
+	}
+
+	// The role is read again so that changes made since login are picked up
+	user, err := h.userRepo.GetByID(userID)
+	if err != nil {
+		writeRefreshError(c, repos.ErrRefreshTokenInvalid)
+		return
+	}
+
+	tokens, err := h.tokenResponse(user.ID, user.Role, sessionID, refreshToken)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Message: "Error generating token",
+			Error:   &models.ErrorInfo{Code: "JWT_ERROR", Details: err.Error()},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Success: true,
+		Message: "Token refreshed",
+		Data:    tokens,
+	})
+}
+
+// Logout godoc
+//
+//	@Summary		Log out
+//	@Description	Revokes the current session. Its access token and refresh tokens stop working immediately, and its WebSocket and event stream connections are closed.
+//	@Tags			auth
+//	@Security		BearerAuth
+//	@Produce		json
+//	@Success		200	{object}	models.SuccessResponse
+//	@Failure		401	{object}	models.ErrorResponse
+//	@Failure		500	{object}	models.ErrorResponse
+//	@Router			/auth/logout [post]
+func (h *AuthHandler) Logout(c *gin.Context) {
+	if err := h.sessionRepo.Revoke(c.GetInt("sessionID"), c.GetInt("userID")); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Message: "Failed to log out",
+			Error:   &models.ErrorInfo{Code: "DB_ERROR", Details: err.Error()},
+		})
+		return
+	}
+	h.hub.DisconnectSessions(c.GetInt("userID"), c.GetInt("sessionID"))
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Success: true,
+		Message: "Logged out",
+	})
+}
+
+// LogoutAll godoc
+//
+//	@Summary		Log out everywhere
+//	@Description	Revokes every session of the current user, including this one, and closes their WebSocket and event stream connections. This also happens automatically when the password changes or the account is deleted.
+//	@Tags			auth
+//	@Security		BearerAuth
+//	@Produce		json
+//	@Success		200	{object}	models.SuccessResponse
+//	@Failure		401	{object}	models.ErrorResponse
+//	@Failure		500	{object}	models.ErrorResponse
+//	@Router			/auth/logout-all [post]
+func (h *AuthHandler) LogoutAll(c *gin.Context) {
+	revoked, err := h.sessionRepo.RevokeAll(c.GetInt("userID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Message: "Failed to log out",
+			Error:   &models.ErrorInfo{Code: "DB_ERROR", Details: err.Error()},
+		})
+		return
+	}
+	h.hub.DisconnectSessions(c.GetInt("userID"))
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Success: true,
+		Message: "Logged out of all sessions",
+		Data: gin.H{
+			"revoked_sessions": revoked,
+		},
+	})
+}
+
+// startSession opens a new login session for the user and issues its first pair of tokens
+func (h *AuthHandler) startSession(c *gin.Context, userID int, role string) (*models.TokenResponse, error) {
+	refreshToken, err := newSecureToken()
+	if err != nil {
+		return nil, err
+	}
+
+	sessionID, err := h.sessionRepo.Create(userID, hashToken(refreshToken),
+		time.Now().Add(h.refreshTokenLifetime), c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		return nil, err
+	}
+	return h.tokenResponse(userID, role, sessionID, refreshToken)
+}
+
+// tokenResponse signs an access token for the session and pairs it with the refresh token
+func (h *AuthHandler) tokenResponse(userID int, role string, sessionID int, refreshToken string) (*models.TokenResponse, error) {
+	expiresAt := time.Now().Add(h.tokenLifetime)
+	token, err := h.tokenKeys.GenerateToken(userID, role, sessionID, expiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.TokenResponse{
+		Token:        token,
+		ExpiresAt:    expiresAt,
+		RefreshToken: refreshToken,
+		Role:         role,
+	}, nil
+}
+
+// newSecureToken returns a random opaque token for refresh and email links. Only its hash is stored.
+func newSecureToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Purposes of signed links, so that a token issued for one cannot be used for another
+const (
+	purposeEmailVerification = "email-verification"
+	purposeAccountUnlock     = "account-unlock"
+	purposeEmailChange       = "email-change"
+)
+
+// signedUserToken signs the purpose, user ID, address and expiry for a link sent by email.
+// The address is part of the signature but not of the token, so a link stops working once the
+// address changes.
+func (h *AuthHandler) signedUserToken(purpose string, userID int, email string, expiresAt time.Time) string {
+	payload := fmt.Sprintf("%d.%d", userID, expiresAt.Unix())
+	mac := hmac.New(sha256.New, h.signingKey)
+	mac.Write([]byte(purpose + "\x00" + payload + "\x00" + email))
+	return payload + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// userFromSignedToken returns the user a signed link was issued to, if the token is valid,
+// unexpired and was issued for the purpose
+func (h *AuthHandler) userFromSignedToken(purpose, token string) (*models.User, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, false
+	}
+	userID, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return nil, false
+	}
+	expiresAt, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || time.Now().Unix() > expiresAt {
+		return nil, false
+	}
+
+	user, err := h.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, false
+	}
+	address := signedAddress(purpose, user)
+	if address == "" {
+		return nil, false
+	}
+	expected := h.signedUserToken(purpose, user.ID, address, time.Unix(expiresAt, 0))
+	if !hmac.Equal([]byte(token), []byte(expected)) {
+		return nil, false
+	}
+	return user, true
+}
+
+// signedAddress is the address links for the purpose are sent to: the pending one for an email
+// change, so that asking for another address makes earlier links stop working
+func signedAddress(purpose string, user *models.User) string {
+	if purpose == purposeEmailChange {
+		return user.PendingEmail
+	}
+	return user.Email
+}
+
+func writeRefreshError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repos.ErrRefreshTokenReused):
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Success: false,
+			Message: "Refresh token was already used; please log in again",
+			Error:   &models.ErrorInfo{Code: "REFRESH_TOKEN_REUSED"},
+		})
+	case errors.Is(err, repos.ErrRefreshTokenInvalid):
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Success: false,
+			Message: "Invalid or expired refresh token",
+			Error:   &models.ErrorInfo{Code: "INVALID_REFRESH_TOKEN"},
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Message: "Failed to refresh token",
+			Error:   &models.ErrorInfo{Code: "DB_ERROR", Details: err.Error()},
+		})
+	}
+}
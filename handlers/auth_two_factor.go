@@ -0,0 +1,371 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/XORbit01/jobseeker-backend/models"
+	"github.com/XORbit01/jobseeker-backend/repos"
+	"github.com/XORbit01/jobseeker-backend/totp"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// totpIssuer is the account label shown in authenticator apps
+	totpIssuer = "Career Pulse"
+	// twoFactorChallengeTTL is how long a login waits for the second factor after the password step
+	twoFactorChallengeTTL = 5 * time.Minute
+	// recoveryCodeCount is how many recovery codes are issued when two-factor authentication is enabled
+	recoveryCodeCount = 10
+	// recoveryCodeAlphabet leaves out characters that are easy to confuse, such as 0 and O
+	recoveryCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+)
+
+// SetupTwoFactor godoc
+//
+//	@Summary		Start two-factor enrollment
+//	@Description	Generates a TOTP secret for an authenticator app. Show the provisioning URI as a QR code, then confirm with a code from the app to enable two-factor authentication. Starting again replaces a secret that was not confirmed.
+//	@Tags			auth
+//	@Security		BearerAuth
+//	@Produce		json
+//	@Success		200	{object}	models.SuccessResponse{data=models.TwoFactorSetup}
+//	@Failure		401	{object}	models.ErrorResponse
+//	@Failure		409	{object}	models.ErrorResponse
+//	@Failure		500	{object}	models.ErrorResponse
+//	@Router			/auth/2fa/setup [post]
+func (h *AuthHandler) SetupTwoFactor(c *gin.Context) {
+	user, err := h.userRepo.GetByID(c.GetInt("userID"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Success: false,
+			Message: "User not found",
+			Error:   &models.ErrorInfo{Code: "USER_NOT_FOUND"},
+		})
+		return
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err == nil {
+		err = h.twoFactorRepo.SavePendingSecret(user.ID, secret)
+	}
+	if err != nil {
+		writeTwoFactorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Success: true,
+		Message: "Scan the code with your authenticator app, then confirm with a code from it",
+		Data: models.TwoFactorSetup{
+			Secret:          secret,
+			ProvisioningURI: totp.ProvisioningURI(totpIssuer, user.Email, secret),
+		},
+	})
+}
+
+// ConfirmTwoFactor godoc
+//
+//	@Summary		Enable two-factor authentication
+//	@Description	Confirms enrollment with a code from the authenticator app and returns recovery codes. Each recovery code can replace an app code once; they are shown only in this response.
+//	@Tags			auth
+//	@Security		BearerAuth
+//	@Accept			json
+//	@Produce		json
+//	@Param			input	body		models.TwoFactorCodeInput	true	"Code from the authenticator app"
+//	@Success		200		{object}	models.SuccessResponse{data=models.TwoFactorRecoveryCodes}
+//	@Failure		400		{object}	models.ErrorResponse
+//	@Failure		401		{object}	models.ErrorResponse
+//	@Failure		409		{object}	models.ErrorResponse
+//	@Failure		500		{object}	models.ErrorResponse
+//	@Router			/auth/2fa/confirm [post]
+func (h *AuthHandler) ConfirmTwoFactor(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	var input models.TwoFactorCodeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Message: "Invalid input",
+			Error:   &models.ErrorInfo{Code: "INVALID_PAYLOAD", Details: err.Error()},
+		})
+		return
+	}
+
+	secret, confirmed, err := h.twoFactorRepo.GetSecret(userID)
+	if err == nil && confirmed {
+		err = repos.ErrTwoFactorEnabled
+	}
+	if err != nil {
+		writeTwoFactorError(c, err)
+		return
+	}
+
+	step, ok := totp.Validate(secret, normalizeTwoFactorCode(input.Code), time.Now())
+	if !ok {
+		writeInvalidTwoFactorCode(c)
+		return
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err == nil {
+		err = h.twoFactorRepo.Confirm(userID, step, hashes)
+	}
+	if err != nil {
+		writeTwoFactorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Success: true,
+		Message: "Two-factor authentication enabled. Store the recovery codes somewhere safe",
+		Data:    models.TwoFactorRecoveryCodes{RecoveryCodes: codes},
+	})
+}
+
+// DisableTwoFactor godoc
+//
+//	@Summary		Disable two-factor authentication
+//	@Description	Requires the password and a code from the authenticator app or a recovery code. Wrong passwords count towards the login lockout.
+//	@Tags			auth
+//	@Security		BearerAuth
+//	@Accept			json
+//	@Produce		json
+//	@Param			input	body		models.DisableTwoFactorInput	true	"Password and code"
+//	@Success		200		{object}	models.SuccessResponse
+//	@Failure		400		{object}	models.ErrorResponse
+//	@Failure		401		{object}	models.ErrorResponse
+//	@Failure		429		{object}	models.ErrorResponse
+//	@Failure		500		{object}	models.ErrorResponse
+//	@Router			/auth/2fa/disable [post]
+func (h *AuthHandler) DisableTwoFactor(c *gin.Context) {
+	var input models.DisableTwoFactorInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Message: "Invalid input",
+			Error:   &models.ErrorInfo{Code: "INVALID_PAYLOAD", Details: err.Error()},
+		})
+		return
+	}
+
+	user, ok := h.userWithPassword(c, input.Password)
+	if !ok {
+		return
+	}
+
+	ok, err := h.checkSecondFactor(user.ID, input.Code)
+	if err != nil {
+		writeTwoFactorError(c, err)
+		return
+	}
+	if !ok {
+		writeInvalidTwoFactorCode(c)
+		return
+	}
+
+	if err := h.twoFactorRepo.Disable(user.ID); err != nil {
+		writeTwoFactorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Success: true,
+		Message: "Two-factor authentication disabled",
+	})
+}
+
+// VerifyTwoFactor godoc
+//
+//	@Summary		Complete a two-factor login
+//	@Description	Exchanges the challenge token returned by login and a code from the authenticator app (or a recovery code) for an access token and a refresh token. A challenge expires after 5 minutes or 5 wrong codes.
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			input	body		models.TwoFactorLoginInput	true	"Challenge token and code"
+//	@Success		200		{object}	models.SuccessResponse{data=models.TokenResponse}
+//	@Failure		400		{object}	models.ErrorResponse
+//	@Failure		401		{object}	models.ErrorResponse
+//	@Failure		429		{object}	models.ErrorResponse
+//	@Failure		500		{object}	models.ErrorResponse
+//	@Router			/auth/2fa/verify [post]
+func (h *AuthHandler) VerifyTwoFactor(c *gin.Context) {
+	var input models.TwoFactorLoginInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Message: "Invalid input",
+			Error:   &models.ErrorInfo{Code: "INVALID_PAYLOAD", Details: err.Error()},
+		})
+		return
+	}
+
+	challengeHash := hashToken(input.ChallengeToken)
+	userID, err := h.twoFactorRepo.AttemptChallenge(challengeHash)
+	if err != nil {
+		writeTwoFactorError(c, err)
+		return
+	}
+
+	user, err := h.userRepo.GetByID(userID)
+	if err != nil {
+		writeTwoFactorError(c, err)
+		return
+	}
+	if h.loginLocked(c, user.Email) {
+		return
+	}
+
+	// Wrong codes count as failed logins, so guessing codes also leads to a lockout
+	ok, err := h.checkSecondFactor(userID, input.Code)
+	if err != nil {
+		writeTwoFactorError(c, err)
+		return
+	}
+	if !ok {
+		h.recordLoginFailure(c, user.Email, user)
+		writeInvalidTwoFactorCode(c)
+		return
+	}
+
+	if err := h.twoFactorRepo.DeleteChallenge(challengeHash); err != nil {
+		writeTwoFactorError(c, err)
+		return
+	}
+	h.resetLoginFailures(user.Email)
+
+	tokens, err := h.startSession(c, user.ID, user.Role)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Message: "Error generating token",
+			Error:   &models.ErrorInfo{Code: "JWT_ERROR", Details: err.Error()},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Success: true,
+		Message: "Login successful",
+		Data:    tokens,
+	})
+}
+
+// challengeTwoFactor answers the password step of a login for users with two-factor authentication
+// by returning a challenge token instead of tokens. It returns false when the user does not use
+// two-factor authentication, and true when it wrote the response.
+func (h *AuthHandler) challengeTwoFactor(c *gin.Context, user *models.User) bool {
+	enabled, err := h.twoFactorRepo.IsEnabled(user.ID)
+	if err != nil {
+		writeTwoFactorError(c, err)
+		return true
+	}
+	if !enabled {
+		return false
+	}
+
+	token, err := newSecureToken()
+	expiresAt := time.Now().Add(twoFactorChallengeTTL)
+	if err == nil {
+		err = h.twoFactorRepo.CreateChallenge(user.ID, hashToken(token), expiresAt)
+	}
+	if err != nil {
+		writeTwoFactorError(c, err)
+		return true
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Success: true,
+		Message: "Enter the code from your authenticator app",
+		Data: models.TwoFactorChallenge{
+			TwoFactorRequired: true,
+			ChallengeToken:    token,
+			ExpiresAt:         expiresAt,
+		},
+	})
+	return true
+}
+
+// checkSecondFactor accepts a current code from the authenticator app, which cannot be replayed,
+// or an unused recovery code
+func (h *AuthHandler) checkSecondFactor(userID int, code string) (bool, error) {
+	secret, confirmed, err := h.twoFactorRepo.GetSecret(userID)
+	if errors.Is(err, repos.ErrTwoFactorNotStarted) || (err == nil && !confirmed) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	code = normalizeTwoFactorCode(code)
+	if len(code) == totp.Digits {
+		step, ok := totp.Validate(secret, code, time.Now())
+		if !ok {
+			return false, nil
+		}
+		return h.twoFactorRepo.UseStep(userID, step)
+	}
+	return h.twoFactorRepo.UseRecoveryCode(userID, hashToken(code))
+}
+
+// normalizeTwoFactorCode removes the spaces and dashes people type in codes and upper-cases recovery codes
+func normalizeTwoFactorCode(code string) string {
+	return strings.ToUpper(strings.NewReplacer(" ", "", "-", "").Replace(code))
+}
+
+// newRecoveryCodes returns recovery codes formatted for display, such as 7KQ2M-XV9TP, and their hashes
+func newRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		b := make([]byte, 10)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		for j := range b {
+			b[j] = recoveryCodeAlphabet[int(b[j])%len(recoveryCodeAlphabet)]
+		}
+		codes[i] = string(b[:5]) + "-" + string(b[5:])
+		hashes[i] = hashToken(string(b))
+	}
+	return codes, hashes, nil
+}
+
+func writeInvalidTwoFactorCode(c *gin.Context) {
+	c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+		Success: false,
+		Message: "Invalid two-factor code",
+		Error:   &models.ErrorInfo{Code: "INVALID_TWO_FACTOR_CODE"},
+	})
+}
+
+func writeTwoFactorError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repos.ErrTwoFactorEnabled):
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Success: false,
+			Message: "Two-factor authentication is already enabled",
+			Error:   &models.ErrorInfo{Code: "TWO_FACTOR_ALREADY_ENABLED"},
+		})
+	case errors.Is(err, repos.ErrTwoFactorNotStarted):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Message: "Start two-factor setup first",
+			Error:   &models.ErrorInfo{Code: "TWO_FACTOR_NOT_STARTED"},
+		})
+	case errors.Is(err, repos.ErrChallengeInvalid):
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Success: false,
+			Message: "This login has expired; please log in again",
+			Error:   &models.ErrorInfo{Code: "INVALID_CHALLENGE"},
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Message: "Failed to process two-factor authentication",
+			Error:   &models.ErrorInfo{Code: "DB_ERROR", Details: err.Error()},
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/XORbit01/jobseeker-backend/mailer"
+	"github.com/XORbit01/jobseeker-backend/models"
+	"github.com/gin-gonic/gin"
+)
+
+// emailVerificationTTL is how long an email verification link stays valid
+const emailVerificationTTL = 48 * time.Hour
+
+// VerifyEmail godoc
+//
+//	@Summary		Verify an email address
+//	@Description	Confirms the address using the token from the verification email. Links expire after 48 hours and stop working if the address changes.
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			input	body		models.VerifyEmailInput	true	"Verification token"
+//	@Success		200		{object}	models.SuccessResponse
+//	@Failure		400		{object}	models.ErrorResponse
+//	@Failure		500		{object}	models.ErrorResponse
+//	@Router			/auth/verify-email [post]
+func (h *AuthHandler) VerifyEmail(c *gin.Context) {
+	var input models.VerifyEmailInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Message: "Invalid input",
+			Error:   &models.ErrorInfo{Code: "INVALID_PAYLOAD", Details: err.Error()},
+		})
+		return
+	}
+
+	user, ok := h.userFromSignedToken(purposeEmailVerification, input.Token)
+	if !ok {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Message: "This verification link is invalid or has expired",
+			Error:   &models.ErrorInfo{Code: "INVALID_VERIFICATION_TOKEN"},
+		})
+		return
+	}
+
+	if user.EmailVerifiedAt == nil {
+		if err := h.userRepo.MarkEmailVerified(user.ID, user.Email); err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Success: false,
+				Message: "Failed to verify email",
+				Error:   &models.ErrorInfo{Code: "DB_ERROR", Details: err.Error()},
+			})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Success: true,
+		Message: "Email verified",
+	})
+}
+
+// ResendVerification godoc
+//
+//	@Summary		Resend the verification email
+//	@Description	Sends a new verification link to the current user's address
+//	@Tags			auth
+//	@Security		BearerAuth
+//	@Produce		json
+//	@Success		200	{object}	models.SuccessResponse
+//	@Failure		400	{object}	models.ErrorResponse
+//	@Failure		401	{object}	models.ErrorResponse
+//	@Failure		500	{object}	models.ErrorResponse
+//	@Router			/auth/resend-verification [post]
+func (h *AuthHandler) ResendVerification(c *gin.Context) {
+	user, err := h.userRepo.GetByID(c.GetInt("userID"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Success: false,
+			Message: "User not found",
+			Error:   &models.ErrorInfo{Code: "USER_NOT_FOUND"},
+		})
+		return
+	}
+	if user.EmailVerifiedAt != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Message: "Your email is already verified",
+			Error:   &models.ErrorInfo{Code: "EMAIL_ALREADY_VERIFIED"},
+		})
+		return
+	}
+
+	if err := h.sendVerificationEmail(user.ID, user.Email); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Message: "Failed to send verification email",
+			Error:   &models.ErrorInfo{Code: "MAIL_ERROR", Details: err.Error()},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Success: true,
+		Message: "Verification email sent",
+	})
+}
+
+// sendVerificationEmail emails the user a signed link that confirms their address
+func (h *AuthHandler) sendVerificationEmail(userID int, email string) error {
+	token := h.signedUserToken(purposeEmailVerification, userID, email, time.Now().Add(emailVerificationTTL))
+	link := fmt.Sprintf("%s/verify-email?token=%s", h.frontendURL, url.QueryEscape(token))
+	return h.mailer.Send(mailer.Message{
+		To:      email,
+		Subject: "Verify your email address",
+		Body: "Welcome! Please confirm your email address by opening this link within 48 hours:\n" +
+			link + "\n\n" +
+			"If you did not create an account, you can ignore this email.",
+	})
+}
+
+// sendVerificationEmailAsync sends the verification email in the background, logging failures
+func (h *AuthHandler) sendVerificationEmailAsync(userID int, email string) {
+	go func() {
+		if err := h.sendVerificationEmail(userID, email); err != nil {
+			log.Printf("sendVerificationEmail: %v", err)
+		}
+	}()
+}
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/XORbit01/jobseeker-backend/models"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// maxAttachmentsPerMessage limits how many files one message may carry
+	maxAttachmentsPerMessage = 5
+	// maxAttachmentSize is the largest accepted file, in bytes
+	maxAttachmentSize = 10 << 20
+	// maxAttachmentNameLength is the longest file name kept for an attachment, in characters
+	maxAttachmentNameLength = 255
+)
+
+// readMessageInput parses a JSON or multipart/form-data message body, writing a 4xx/5xx response on failure.
+// Multipart files under the `attachments` key are stored through the same pipeline as UploadFile.
+func readMessageInput(c *gin.Context) (models.MessageInput, []models.MessageAttachment, bool) {
+	var input models.MessageInput
+	var ok bool
+
+	if !strings.HasPrefix(c.ContentType(), "multipart/form-data") {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Success: false,
+				Message: "Invalid message input",
+				Error:   &models.ErrorInfo{Code: "VALIDATION_ERROR", Details: err.Error()},
+			})
+			return input, nil, false
+		}
+		if strings.TrimSpace(input.Content) == "" && input.TemplateID == 0 {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Success: false,
+				Message: "Invalid message input",
+				Error:   &models.ErrorInfo{Code: "VALIDATION_ERROR", Details: "content or template_id is required"},
+			})
+			return input, nil, false
+		}
+		return input, nil, true
+	}
+
+	form, err := c.MultipartForm()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Message: "Invalid message input",
+			Error:   &models.ErrorInfo{Code: "VALIDATION_ERROR", Details: err.Error()},
+		})
+		return input, nil, false
+	}
+
+	input.Content = c.PostForm("content")
+	files := form.File["attachments"]
+
+	if input.TemplateID, ok = optionalFormID(c, "template_id"); !ok {
+		return input, nil, false
+	}
+	if input.JobID, ok = optionalFormID(c, "job_id"); !ok {
+		return input, nil, false
+	}
+
+	if strings.TrimSpace(input.Content) == "" && input.TemplateID == 0 && len(files) == 0 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Message: "Invalid message input",
+			Error:   &models.ErrorInfo{Code: "VALIDATION_ERROR", Details: "content, template_id or at least one attachment is required"},
+		})
+		return input, nil, false
+	}
+	if len(files) > maxAttachmentsPerMessage {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Message: fmt.Sprintf("A message can carry at most %d attachments", maxAttachmentsPerMessage),
+			Error:   &models.ErrorInfo{Code: "TOO_MANY_ATTACHMENTS"},
+		})
+		return input, nil, false
+	}
+	for _, file := range files {
+		if file.Size > maxAttachmentSize {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Success: false,
+				Message: fmt.Sprintf("Attachment %q exceeds the %d MB limit", file.Filename, maxAttachmentSize>>20),
+				Error:   &models.ErrorInfo{Code: "ATTACHMENT_TOO_LARGE"},
+			})
+			return input, nil, false
+		}
+	}
+
+	attachments := make([]models.MessageAttachment, 0, len(files))
+	for _, file := range files {
+		attachment, err := storeAttachment(c, file)
+		if err != nil {
+			removeAttachmentFiles(attachments)
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Success: false,
+				Message: "Failed to save attachment",
+				Error:   &models.ErrorInfo{Code: "UPLOAD_ERROR", Details: err.Error()},
+			})
+			return input, nil, false
+		}
+		attachments = append(attachments, attachment)
+	}
+
+	return input, attachments, true
+}
+
+// optionalFormID reads an optional positive ID from a form field, writing a 400 response when it is invalid
+func optionalFormID(c *gin.Context, field string) (int, bool) {
+	value := c.PostForm(field)
+	if value == "" {
+		return 0, true
+	}
+	id, err := strconv.Atoi(value)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Message: "Invalid message input",
+			Error:   &models.ErrorInfo{Code: "VALIDATION_ERROR", Details: field + " must be a positive ID"},
+		})
+		return 0, false
+	}
+	return id, true
+}
+
+// storeAttachment saves one uploaded file and describes it as a message attachment
+func storeAttachment(c *gin.Context, file *multipart.FileHeader) (models.MessageAttachment, error) {
+	mimeType, err := detectMimeType(file)
+	if err != nil {
+		return models.MessageAttachment{}, err
+	}
+
+	path, url, err := storeUpload(c, file)
+	if err != nil {
+		return models.MessageAttachment{}, err
+	}
+
+	return models.MessageAttachment{
+		FileName:  attachmentName(file.Filename),
+		MimeType:  mimeType,
+		SizeBytes: file.Size,
+		URL:       url,
+		Path:      path,
+	}, nil
+}
+
+// attachmentName returns the name an attachment is shown under, cut to maxAttachmentNameLength characters
+func attachmentName(filename string) string {
+	name := []rune(filepath.Base(filename))
+	if len(name) <= maxAttachmentNameLength {
+		return string(name)
+	}
+	// Keep the extension so the shortened name still tells what kind of file it is
+	ext := len([]rune(uploadExtension(filename)))
+	return string(name[:maxAttachmentNameLength-ext]) + string(name[len(name)-ext:])
+}
+
+// removeAttachmentFiles deletes stored files of a message that could not be saved
+func removeAttachmentFiles(attachments []models.MessageAttachment) {
+	for _, a := range attachments {
+		if a.Path == "" {
+			continue
+		}
+		if err := os.Remove(a.Path); err != nil && !os.IsNotExist(err) {
+			log.Printf("removeAttachmentFiles: %v", err)
+		}
+	}
+}
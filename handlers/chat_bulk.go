@@ -0,0 +1,282 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/XORbit01/jobseeker-backend/models"
+	"github.com/XORbit01/jobseeker-backend/repos"
+	"github.com/gin-gonic/gin"
+)
+
+// CreateBulkMessage godoc
+//
+//	@Summary		Message all applicants of a job
+//	@Description	Posts one message in each applicant's application conversation, optionally only to applications with a given status. Send either `content` or one of your `template_id`s; both may use {{first_name}}, {{job_title}} and {{company_name}}. Delivery runs in the background; poll the returned bulk message for progress and per-recipient results. Requires role: employer
+//	@Tags			Chat
+//	@Security		BearerAuth
+//	@Accept			json
+//	@Produce		json
+//	@Param			input	body		models.BulkMessageInput	true	"Job, optional status filter and message"
+//	@Success		202		{object}	models.SuccessResponse{data=models.BulkMessage}
+//	@Failure		400		{object}	models.ErrorResponse
+//	@Failure		401		{object}	models.ErrorResponse
+//	@Failure		403		{object}	models.ErrorResponse
+//	@Failure		404		{object}	models.ErrorResponse
+//	@Failure		500		{object}	models.ErrorResponse
+//	@Router			/chats/bulk-messages [post]
+func (h *ChatHandler) CreateBulkMessage(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	var input models.BulkMessageInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Message: "Invalid bulk message input",
+			Error:   &models.ErrorInfo{Code: "VALIDATION_ERROR", Details: err.Error()},
+		})
+		return
+	}
+	hasContent := strings.TrimSpace(input.Content) != ""
+	if hasContent == (input.TemplateID != 0) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Message: "Send either content or a template",
+			Error:   &models.ErrorInfo{Code: "VALIDATION_ERROR"},
+		})
+		return
+	}
+
+	jobTitle, err := h.templateRepo.GetJobTitle(input.JobID, userID)
+	if err != nil {
+		writeBulkMessageError(c, err)
+		return
+	}
+	if jobTitle == "" {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Success: false,
+			Message: "Job not found",
+			Error:   &models.ErrorInfo{Code: "JOB_NOT_FOUND"},
+		})
+		return
+	}
+
+	body := input.Content
+	if input.TemplateID != 0 {
+		template, err := h.templateRepo.GetByID(input.TemplateID, userID)
+		if err != nil {
+			writeTemplateError(c, err)
+			return
+		}
+		body = template.Body
+	}
+	if !checkTemplateVariables(c, body) {
+		return
+	}
+
+	id, err := h.bulkRepo.Create(userID, input, body)
+	if err != nil {
+		writeBulkMessageError(c, err)
+		return
+	}
+
+	bulk, err := h.bulkRepo.GetByID(id)
+	if err != nil {
+		writeBulkMessageError(c, err)
+		return
+	}
+
+	go h.runBulkMessage(id)
+
+	c.JSON(http.StatusAccepted, models.SuccessResponse{
+		Success: true,
+		Message: "Bulk message queued",
+		Data:    bulk,
+	})
+}
+
+// GetBulkMessage godoc
+//
+//	@Summary		Get the progress of a bulk message
+//	@Description	Returns the delivery status, counters and the result for each recipient. Requires role: employer
+//	@Tags			Chat
+//	@Security		BearerAuth
+//	@Produce		json
+//	@Param			bulk_message_id	path		int	true	"Bulk message ID"
+//	@Success		200				{object}	models.SuccessResponse{data=models.BulkMessage}
+//	@Failure		400				{object}	models.ErrorResponse
+//	@Failure		401				{object}	models.ErrorResponse
+//	@Failure		403				{object}	models.ErrorResponse
+//	@Failure		404				{object}	models.ErrorResponse
+//	@Failure		500				{object}	models.ErrorResponse
+//	@Router			/chats/bulk-messages/{bulk_message_id} [get]
+func (h *ChatHandler) GetBulkMessage(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("bulk_message_id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Message: "Invalid bulk message ID",
+			Error:   &models.ErrorInfo{Code: "INVALID_INPUT"},
+		})
+		return
+	}
+
+	bulk, err := h.bulkRepo.GetByID(id)
+	if err == nil && bulk.EmployerUserID != c.GetInt("userID") {
+		err = repos.ErrBulkMessageNotFound
+	}
+	if err != nil {
+		writeBulkMessageError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Success: true,
+		Message: "Bulk message retrieved",
+		Data:    bulk,
+	})
+}
+
+// resumeBulkMessages finishes bulk messages that were interrupted by a restart. It has to be
+// called before the server accepts requests, so that only bulk messages left unfinished by the
+// previous run are picked up. Recipients that were being sent to at the time are marked failed
+// rather than sent to again, and the rest are sent in the background.
+func (h *ChatHandler) resumeBulkMessages() {
+	ids, err := h.bulkRepo.GetUnfinishedIDs()
+	if err != nil {
+		log.Printf("resumeBulkMessages: %v", err)
+		return
+	}
+
+	resumed := make([]int, 0, len(ids))
+	for _, id := range ids {
+		if err := h.bulkRepo.FailInterrupted(id); err != nil {
+			log.Printf("resumeBulkMessages %d: %v", id, err)
+			continue
+		}
+		resumed = append(resumed, id)
+	}
+
+	go func() {
+		for _, id := range resumed {
+			h.runBulkMessage(id)
+		}
+	}()
+}
+
+// runBulkMessage delivers a bulk message to every recipient that has not been processed yet
+func (h *ChatHandler) runBulkMessage(id int) {
+	bulk, err := h.bulkRepo.GetByID(id)
+	if err != nil {
+		log.Printf("runBulkMessage %d: %v", id, err)
+		return
+	}
+	if err := h.bulkRepo.MarkStarted(id); err != nil {
+		log.Printf("runBulkMessage %d: %v", id, err)
+		return
+	}
+
+	recipients, err := h.bulkRepo.GetPendingRecipients(id)
+	if err != nil {
+		log.Printf("runBulkMessage %d: %v", id, err)
+		return
+	}
+	for _, rec := range recipients {
+		claimed, err := h.bulkRepo.ClaimRecipient(id, rec.ApplicationID)
+		if err != nil {
+			log.Printf("runBulkMessage %d: %v", id, err)
+			return
+		}
+		if !claimed {
+			continue
+		}
+		if err := h.bulkRepo.RecordResult(id, h.sendBulkMessage(bulk, rec)); err != nil {
+			log.Printf("runBulkMessage %d: %v", id, err)
+			return
+		}
+	}
+
+	if err := h.bulkRepo.MarkCompleted(id); err != nil {
+		log.Printf("runBulkMessage %d: %v", id, err)
+	}
+}
+
+// sendBulkMessage posts the message in one applicant's application conversation and reports the outcome.
+// Failures are recorded as one of the models.BulkError codes; the underlying error is only logged.
+func (h *ChatHandler) sendBulkMessage(bulk *models.BulkMessage, rec models.BulkMessageRecipient) models.BulkMessageRecipient {
+	fail := func(code string, err error) models.BulkMessageRecipient {
+		if err != nil {
+			log.Printf("sendBulkMessage %d, application %d: %v", bulk.ID, rec.ApplicationID, err)
+		}
+		rec.Status = models.BulkRecipientFailed
+		rec.Error = code
+		return rec
+	}
+
+	conv, _, err := h.chatRepo.GetOrCreateApplicationConversation(rec.ApplicationID, bulk.JobID, bulk.EmployerUserID,
+		[]int{rec.UserID, bulk.EmployerUserID})
+	if err != nil {
+		return fail(models.BulkErrorDelivery, err)
+	}
+	if conv == nil || !slices.Contains(conv.ParticipantIDs, bulk.EmployerUserID) {
+		return fail(models.BulkErrorNotParticipant, nil)
+	}
+	rec.ConversationID = &conv.ID
+
+	content, missing, err := h.fillTemplate(bulk.Body, bulk.EmployerUserID, rec.UserID, &bulk.JobID, 0)
+	if err != nil {
+		return fail(models.BulkErrorDelivery, err)
+	}
+	if len(missing) > 0 {
+		return fail(models.BulkErrorTemplateVariable, nil)
+	}
+
+	msgID, err := h.chatRepo.SaveMessage(models.Message{
+		ConversationID: conv.ID,
+		SenderID:       bulk.EmployerUserID,
+		ReceiverID:     rec.UserID,
+		Content:        content,
+	})
+	if errors.Is(err, repos.ErrBlocked) {
+		return fail(models.BulkErrorUserBlocked, nil)
+	}
+	if err != nil {
+		return fail(models.BulkErrorDelivery, err)
+	}
+	rec.Status = models.BulkRecipientSent
+	rec.MessageID = &msgID
+
+	if msg, err := h.chatRepo.GetMessageByID(msgID); err == nil {
+		h.publishMessage(msg)
+	} else {
+		log.Printf("sendBulkMessage: %v", err)
+	}
+	return rec
+}
+
+func writeBulkMessageError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repos.ErrBulkMessageNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Success: false,
+			Message: "Bulk message not found",
+			Error:   &models.ErrorInfo{Code: "BULK_MESSAGE_NOT_FOUND"},
+		})
+	case errors.Is(err, repos.ErrNoRecipients):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Message: "No applicants match this selection",
+			Error:   &models.ErrorInfo{Code: "NO_RECIPIENTS"},
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Message: "Failed to process bulk message",
+			Error:   &models.ErrorInfo{Code: "DB_ERROR", Details: err.Error()},
+		})
+	}
+}
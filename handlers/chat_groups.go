@@ -0,0 +1,324 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/XORbit01/jobseeker-backend/config"
+	"github.com/XORbit01/jobseeker-backend/models"
+	"github.com/XORbit01/jobseeker-backend/realtime"
+	"github.com/gin-gonic/gin"
+)
+
+// maxGroupSize is the largest number of members a conversation may have
+const maxGroupSize = 50
+
+// CreateGroup godoc
+//
+//	@Summary		Create a group conversation
+//	@Description	Starts a titled conversation with several members, for example a candidate, a recruiter and a hiring manager. You are added automatically. Under the restricted contact policy job seekers cannot create groups, and employers may add other employers freely but candidates only if they could message them directly.
+//	@Tags			Chat
+//	@Security		BearerAuth
+//	@Accept			json
+//	@Produce		json
+//	@Param			input	body		models.GroupInput	true	"Group details"
+//	@Success		201		{object}	models.SuccessResponse{data=models.Conversation}
+//	@Failure		400		{object}	models.ErrorResponse
+//	@Failure		401		{object}	models.ErrorResponse
+//	@Failure		403		{object}	models.ErrorResponse
+//	@Failure		404		{object}	models.ErrorResponse
+//	@Failure		500		{object}	models.ErrorResponse
+//	@Router			/chats/groups [post]
+func (h *ChatHandler) CreateGroup(c *gin.Context) {
+	userID := c.GetInt("userID")
+	role := c.GetString("userRole")
+
+	var input models.GroupInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Message: "Invalid group input",
+			Error:   &models.ErrorInfo{Code: "VALIDATION_ERROR", Details: err.Error()},
+		})
+		return
+	}
+
+	if !h.canManageMembers(c, role) {
+		return
+	}
+
+	members := uniqueIDsExcept(input.ParticipantIDs, userID)
+	if len(members) == 0 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Message: "A group needs at least one other member",
+			Error:   &models.ErrorInfo{Code: "VALIDATION_ERROR"},
+		})
+		return
+	}
+	if !h.checkGroupMembers(c, userID, role, members) {
+		return
+	}
+
+	conv, err := h.chatRepo.CreateGroupConversation(userID, input.Title, members)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Message: "Failed to create group",
+			Error:   &models.ErrorInfo{Code: "DB_ERROR", Details: err.Error()},
+		})
+		return
+	}
+
+	h.hub.SendToUsers(conv.ParticipantIDs, realtime.Event{Type: realtime.EventConversationUpdated, Data: conv})
+
+	c.JSON(http.StatusCreated, models.SuccessResponse{
+		Success: true,
+		Message: "Group created",
+		Data:    conv,
+	})
+}
+
+// AddParticipants godoc
+//
+//	@Summary		Add members to a conversation
+//	@Description	Adds people to a group or to a job/application thread, which then becomes a group. General one-to-one threads cannot be extended; create a group instead. The same contact rules as for creating a group apply.
+//	@Tags			Chat
+//	@Security		BearerAuth
+//	@Accept			json
+//	@Produce		json
+//	@Param			conversation_id	path		int							true	"Conversation ID"
+//	@Param			input			body		models.ParticipantsInput	true	"Users to add"
+//	@Success		200				{object}	models.SuccessResponse{data=models.Conversation}
+//	@Failure		400				{object}	models.ErrorResponse
+//	@Failure		401				{object}	models.ErrorResponse
+//	@Failure		403				{object}	models.ErrorResponse
+//	@Failure		404				{object}	models.ErrorResponse
+//	@Failure		500				{object}	models.ErrorResponse
+//	@Router			/chats/conversations/{conversation_id}/participants [post]
+func (h *ChatHandler) AddParticipants(c *gin.Context) {
+	userID := c.GetInt("userID")
+	role := c.GetString("userRole")
+
+	conv, ok := h.memberConversation(c, userID)
+	if !ok {
+		return
+	}
+
+	var input models.ParticipantsInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Message: "Invalid participants input",
+			Error:   &models.ErrorInfo{Code: "VALIDATION_ERROR", Details: err.Error()},
+		})
+		return
+	}
+
+	if !conv.IsGroup && conv.JobID == nil && conv.ApplicationID == nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Message: "People cannot be added to a one-to-one conversation, create a group instead",
+			Error:   &models.ErrorInfo{Code: "DIRECT_CONVERSATION"},
+		})
+		return
+	}
+	if !h.canManageMembers(c, role) {
+		return
+	}
+
+	newMembers := make([]int, 0, len(input.UserIDs))
+	for _, id := range uniqueIDsExcept(input.UserIDs, userID) {
+		if !slices.Contains(conv.ParticipantIDs, id) {
+			newMembers = append(newMembers, id)
+		}
+	}
+	if len(newMembers) == 0 {
+		c.JSON(http.StatusOK, models.SuccessResponse{
+			Success: true,
+			Message: "No new participants",
+			Data:    conv,
+		})
+		return
+	}
+	if len(conv.ParticipantIDs)+len(newMembers) > maxGroupSize {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Message: "Conversations are limited to " + strconv.Itoa(maxGroupSize) + " members",
+			Error:   &models.ErrorInfo{Code: "GROUP_TOO_LARGE"},
+		})
+		return
+	}
+	if !h.checkGroupMembers(c, userID, role, newMembers) {
+		return
+	}
+
+	if err := h.chatRepo.AddParticipants(conv.ID, userID, newMembers); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Message: "Failed to add participants",
+			Error:   &models.ErrorInfo{Code: "DB_ERROR", Details: err.Error()},
+		})
+		return
+	}
+
+	h.respondWithUpdatedConversation(c, conv.ID, nil, "Participants added")
+}
+
+// RemoveParticipant godoc
+//
+//	@Summary		Remove a member from a group conversation
+//	@Description	Any member may leave by removing themselves. Only the group creator or an admin may remove other members.
+//	@Tags			Chat
+//	@Security		BearerAuth
+//	@Produce		json
+//	@Param			conversation_id	path		int	true	"Conversation ID"
+//	@Param			user_id			path		int	true	"User to remove"
+//	@Success		200				{object}	models.SuccessResponse{data=models.Conversation}
+//	@Failure		400				{object}	models.ErrorResponse
+//	@Failure		401				{object}	models.ErrorResponse
+//	@Failure		403				{object}	models.ErrorResponse
+//	@Failure		404				{object}	models.ErrorResponse
+//	@Failure		500				{object}	models.ErrorResponse
+//	@Router			/chats/conversations/{conversation_id}/participants/{user_id} [delete]
+func (h *ChatHandler) RemoveParticipant(c *gin.Context) {
+	userID := c.GetInt("userID")
+	role := c.GetString("userRole")
+
+	targetID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil || targetID <= 0 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Message: "Invalid user ID",
+			Error:   &models.ErrorInfo{Code: "INVALID_INPUT"},
+		})
+		return
+	}
+
+	conv, ok := h.memberConversation(c, userID)
+	if !ok {
+		return
+	}
+
+	if !conv.IsGroup {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Message: "Only group conversations have removable members",
+			Error:   &models.ErrorInfo{Code: "NOT_A_GROUP"},
+		})
+		return
+	}
+	isCreator := conv.CreatedBy != nil && *conv.CreatedBy == userID
+	if targetID != userID && !isCreator && role != "admin" {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Success: false,
+			Message: "Only the group creator can remove other members",
+			Error:   &models.ErrorInfo{Code: "FORBIDDEN"},
+		})
+		return
+	}
+
+	removed, err := h.chatRepo.RemoveParticipant(conv.ID, targetID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Message: "Failed to remove participant",
+			Error:   &models.ErrorInfo{Code: "DB_ERROR", Details: err.Error()},
+		})
+		return
+	}
+	if !removed {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Success: false,
+			Message: "User is not a member of this conversation",
+			Error:   &models.ErrorInfo{Code: "PARTICIPANT_NOT_FOUND"},
+		})
+		return
+	}
+
+	h.respondWithUpdatedConversation(c, conv.ID, []int{targetID}, "Participant removed")
+}
+
+// memberConversation loads the conversation from the :conversation_id parameter and checks
+// that the user is a member. When it fails it writes the error response and returns false.
+func (h *ChatHandler) memberConversation(c *gin.Context, userID int) (*models.Conversation, bool) {
+	convID, err := strconv.Atoi(c.Param("conversation_id"))
+	if err != nil || convID <= 0 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Message: "Invalid conversation ID",
+			Error:   &models.ErrorInfo{Code: "INVALID_INPUT"},
+		})
+		return nil, false
+	}
+
+	conv, err := h.chatRepo.GetConversation(convID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Message: "Failed to fetch conversation",
+			Error:   &models.ErrorInfo{Code: "DB_ERROR", Details: err.Error()},
+		})
+		return nil, false
+	}
+	if conv == nil || !slices.Contains(conv.ParticipantIDs, userID) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Success: false,
+			Message: "Conversation not found",
+			Error:   &models.ErrorInfo{Code: "CONVERSATION_NOT_FOUND"},
+		})
+		return nil, false
+	}
+	return conv, true
+}
+
+// canManageMembers reports whether users with this role may create groups or add members.
+// When they may not, it writes the error response and returns false.
+func (h *ChatHandler) canManageMembers(c *gin.Context, role string) bool {
+	if role == "job_seeker" && h.contactPolicy != config.ContactPolicyOpen {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Success: false,
+			Message: "Job seekers cannot add people to conversations",
+			Error:   &models.ErrorInfo{Code: "FORBIDDEN_CONTACT"},
+		})
+		return false
+	}
+	return true
+}
+
+// respondWithUpdatedConversation reloads a conversation after a membership change, tells its
+// members (and any users who just left) about it, and writes the response
+func (h *ChatHandler) respondWithUpdatedConversation(c *gin.Context, convID int, formerMembers []int, message string) {
+	conv, err := h.chatRepo.GetConversation(convID)
+	if err != nil || conv == nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Message: "Failed to fetch conversation",
+			Error:   &models.ErrorInfo{Code: "DB_ERROR"},
+		})
+		return
+	}
+
+	h.hub.SendToUsers(append(slices.Clone(conv.ParticipantIDs), formerMembers...), realtime.Event{
+		Type: realtime.EventConversationUpdated,
+		Data: conv,
+	})
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Success: true,
+		Message: message,
+		Data:    conv,
+	})
+}
+
+// uniqueIDsExcept removes duplicates and the given ID from a list of user IDs
+func uniqueIDsExcept(ids []int, except int) []int {
+	result := make([]int, 0, len(ids))
+	for _, id := range ids {
+		if id != except && id > 0 && !slices.Contains(result, id) {
+			result = append(result, id)
+		}
+	}
+	return result
+}
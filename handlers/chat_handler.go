@@ -2,60 +2,121 @@ package handlers
 
 import (
 	"database/sql"
+	"errors"
+	"io"
+	"log"
 	"net/http"
 	"strconv"
+	"time"
 
+	"github.com/XORbit01/jobseeker-backend/config"
+	"github.com/XORbit01/jobseeker-backend/middleware"
 	"github.com/XORbit01/jobseeker-backend/models"
+	"github.com/XORbit01/jobseeker-backend/realtime"
 	"github.com/XORbit01/jobseeker-backend/repos"
 	"github.com/gin-gonic/gin"
 )
 
 type ChatHandler struct {
-	chatRepo *repos.ChatRepository
+	chatRepo       *repos.ChatRepository
+	moderationRepo *repos.ModerationRepository
+	templateRepo   *repos.TemplateRepository
+	bulkRepo       *repos.BulkMessageRepository
+	sessionRepo    *repos.SessionRepository
+	hub            *realtime.Hub
+	editWindow     time.Duration
+	contactPolicy  string
 }
 
-func NewChatHandler(db *sql.DB) *ChatHandler {
+func NewChatHandler(db *sql.DB, hub *realtime.Hub, cfg *config.Config) *ChatHandler {
 	return &ChatHandler{
-		chatRepo: repos.NewChatRepository(db),
+		chatRepo:       repos.NewChatRepository(db),
+		moderationRepo: repos.NewModerationRepository(db),
+		templateRepo:   repos.NewTemplateRepository(db),
+		bulkRepo:       repos.NewBulkMessageRepository(db),
+		sessionRepo:    repos.NewSessionRepository(db),
+		hub:            hub,
+		editWindow:     cfg.MessageEditWindow,
+		contactPolicy:  cfg.ContactPolicy,
 	}
 }
 
-func RegisterChatRoutes(router *gin.RouterGroup, db *sql.DB) {
-	h := NewChatHandler(db)
+func RegisterChatRoutes(router *gin.RouterGroup, db *sql.DB, hub *realtime.Hub, cfg *config.Config) {
+	h := NewChatHandler(db, hub, cfg)
+	hub.OnPresence(h.announcePresence)
+	h.resumeBulkMessages()
+
+	verified := middleware.VerifiedEmailMiddleware(repos.NewUserRepository(db), cfg.RequiresVerifiedEmail(config.VerifiedActionMessaging))
+
 	chat := router.Group("/chats")
 	{
 		chat.GET("/", h.GetConversations)                     // List all conversations
-		chat.POST("/:user_id/messages", h.SendMessage)        // Send message to user (creates conversation)
+		chat.GET("/ws", h.ServeWS)                            // Real-time push connection
+		chat.GET("/inbox", h.GetInbox)                        // Conversations with previews and unread counts
+		chat.GET("/search", h.SearchMessages)                 // Full-text search across your messages
 		chat.GET("/:conversation_id/messages", h.GetMessages) // Get messages in a conversation
 		chat.PUT("/:conversation_id/read", h.MarkAsRead)      // Mark messages as read in conversation
+		chat.PATCH("/messages/:message_id", h.EditMessage)    // Edit own message within the edit window
+		chat.DELETE("/messages/:message_id", h.DeleteMessage) // Unsend own message within the edit window
+
+		// Sending messages, which the verification policy may reserve for verified emails
+		sending := chat.Group("", verified)
+		sending.POST("/:user_id/messages", h.SendMessage) // Send message to user (creates conversation)
+		sending.POST("/groups", h.CreateGroup)
+		sending.POST("/conversations/:conversation_id/messages", h.SendConversationMessage)
+
+		// Actions on a specific conversation
+		chat.POST("/conversations/:conversation_id/participants", h.AddParticipants)
+		chat.DELETE("/conversations/:conversation_id/participants/:user_id", h.RemoveParticipant)
+		chat.PATCH("/conversations/:conversation_id/settings", h.UpdateConversationSettings)
+
+		// Blocking and reporting
+		chat.GET("/blocks", h.GetBlockedUsers)         // List users you have blocked
+		chat.POST("/blocks/:user_id", h.BlockUser)     // Stop a user from messaging you
+		chat.DELETE("/blocks/:user_id", h.UnblockUser) // Remove a user from your block list
+		chat.POST("/reports", h.ReportUser)            // Flag a user, conversation or message to moderators
+
+		// Recruiter tools: message templates and bulk messages to applicants
+		employer := chat.Group("", middleware.RoleMiddleware("employer"))
+		employer.GET("/templates", h.GetTemplates)
+		employer.POST("/templates", h.CreateTemplate)
+		employer.PUT("/templates/:template_id", h.UpdateTemplate)
+		employer.DELETE("/templates/:template_id", h.DeleteTemplate)
+		employer.POST("/bulk-messages", verified, h.CreateBulkMessage)
+		employer.GET("/bulk-messages/:bulk_message_id", h.GetBulkMessage)
+
+		// Moderation
+		admin := chat.Group("", middleware.RoleMiddleware("admin"))
+		admin.GET("/messages/:message_id/revisions", h.GetMessageRevisions)
+		admin.GET("/reports", h.GetReports)
+		admin.PUT("/reports/:report_id", h.UpdateReportStatus)
 	}
 }
 
 // GetConversations godoc
 //
-//	@Summary	Get all conversations for the current user
-//	@Tags		Chat
-//	@Security	BearerAuth
-//	@Produce	json
-//	@Success	200	{object}	models.SuccessResponse{data=[]models.Conversation}
-//	@Failure	401	{object}	models.ErrorResponse
-//	@Failure	500	{object}	models.ErrorResponse
-//	@Router		/chats/ [get]
-//
-// GetConversations godoc
-//
-//	@Summary	Get all conversations with message count
-//	@Tags		Chat
-//	@Security	BearerAuth
-//	@Produce	json
-//	@Success	200	{object}	models.SuccessResponse{data=[]models.ConversationWithStats}
-//	@Failure	401	{object}	models.ErrorResponse
-//	@Failure	500	{object}	models.ErrorResponse
-//	@Router		/chats/ [get]
+//	@Summary		Get conversations with message count
+//	@Description	Returns a page of conversations, newest first. Pass the returned `next_cursor` as `before` to load older conversations.
+//	@Tags			Chat
+//	@Security		BearerAuth
+//	@Produce		json
+//	@Param			before	query		int	false	"Return conversations with an ID lower than this cursor"
+//	@Param			after	query		int	false	"Return conversations with an ID higher than this cursor"
+//	@Param			limit	query		int	false	"Page size (max 100)"	default(50)
+//	@Success		200		{object}	models.SuccessResponse{data=[]models.ConversationWithStats}
+//	@Failure		400		{object}	models.ErrorResponse
+//	@Failure		401		{object}	models.ErrorResponse
+//	@Failure		500		{object}	models.ErrorResponse
+//	@Router			/chats/ [get]
 func (h *ChatHandler) GetConversations(c *gin.Context) {
 	userID := c.GetInt("userID")
 
-	convs, count, err := h.chatRepo.GetConversationsWithStatsForUser(userID)
+	page, ok := bindCursorParams(c)
+	if !ok {
+		return
+	}
+
+	convs, nextCursor, err := h.chatRepo.GetConversationsWithStatsForUser(userID, page)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Success: false,
@@ -65,30 +126,153 @@ func (h *ChatHandler) GetConversations(c *gin.Context) {
 		return
 	}
 
+	for i := range convs {
+		if convs[i].Counterpart != nil {
+			convs[i].Counterpart.Online = h.hub.IsOnline(convs[i].Counterpart.UserID)
+		}
+	}
+
 	c.JSON(http.StatusOK, models.SuccessResponse{
 		Success: true,
 		Message: "Conversations retrieved",
 		Data: gin.H{
-			"total":         count,
+			"total":         len(convs),
 			"conversations": convs,
+			"next_cursor":   nextCursor,
 		},
 	})
 }
 
+// GetInbox godoc
+//
+//	@Summary		Get the conversation inbox
+//	@Description	Returns conversations with the counterpart's name and avatar, the last message preview, the unread count and your archive/mute/pin settings. Pinned conversations come first, then the most recent activity. Archived conversations are hidden unless you ask for them.
+//	@Tags			Chat
+//	@Security		BearerAuth
+//	@Produce		json
+//	@Param			filter	query		string	false	"Which conversations to show"	Enums(active, archived, pinned, muted, unread, all)	default(active)
+//	@Success		200		{object}	models.SuccessResponse{data=[]models.InboxEntry}
+//	@Failure		400		{object}	models.ErrorResponse
+//	@Failure		401		{object}	models.ErrorResponse
+//	@Failure		500		{object}	models.ErrorResponse
+//	@Router			/chats/inbox [get]
+func (h *ChatHandler) GetInbox(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	var params models.InboxParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Message: "Invalid inbox filter",
+			Error:   &models.ErrorInfo{Code: "INVALID_PARAMS", Details: err.Error()},
+		})
+		return
+	}
+
+	inbox, err := h.chatRepo.GetInboxForUser(userID, params.Filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Message: "Failed to retrieve inbox",
+			Error:   &models.ErrorInfo{Code: "DB_ERROR", Details: err.Error()},
+		})
+		return
+	}
+
+	for i := range inbox {
+		if inbox[i].Counterpart != nil {
+			inbox[i].Counterpart.Online = h.hub.IsOnline(inbox[i].Counterpart.UserID)
+		}
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Success: true,
+		Message: "Inbox retrieved",
+		Data:    inbox,
+	})
+}
+
+// UpdateConversationSettings godoc
+//
+//	@Summary		Archive, mute or pin a conversation
+//	@Description	Changes how a conversation appears for you only; other participants are not affected. Omitted fields keep their value. An archived conversation returns to the inbox when a new message arrives, unless it is also muted.
+//	@Tags			Chat
+//	@Security		BearerAuth
+//	@Accept			json
+//	@Produce		json
+//	@Param			conversation_id	path		int									true	"Conversation ID"
+//	@Param			input			body		models.ConversationSettingsInput	true	"Settings to change"
+//	@Success		200				{object}	models.SuccessResponse{data=models.ConversationSettings}
+//	@Failure		400				{object}	models.ErrorResponse
+//	@Failure		401				{object}	models.ErrorResponse
+//	@Failure		404				{object}	models.ErrorResponse
+//	@Failure		500				{object}	models.ErrorResponse
+//	@Router			/chats/conversations/{conversation_id}/settings [patch]
+func (h *ChatHandler) UpdateConversationSettings(c *gin.Context) {
+	userID := c.GetInt("userID")
+	convID, err := strconv.Atoi(c.Param("conversation_id"))
+	if err != nil || convID <= 0 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Message: "Invalid conversation ID",
+			Error:   &models.ErrorInfo{Code: "INVALID_INPUT"},
+		})
+		return
+	}
+
+	var input models.ConversationSettingsInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Message: "Invalid settings input",
+			Error:   &models.ErrorInfo{Code: "VALIDATION_ERROR", Details: err.Error()},
+		})
+		return
+	}
+
+	settings, err := h.chatRepo.UpdateConversationSettings(convID, userID, input)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Message: "Failed to update conversation settings",
+			Error:   &models.ErrorInfo{Code: "DB_ERROR", Details: err.Error()},
+		})
+		return
+	}
+	if settings == nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Success: false,
+			Message: "Conversation not found",
+			Error:   &models.ErrorInfo{Code: "CONVERSATION_NOT_FOUND"},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Success: true,
+		Message: "Conversation settings updated",
+		Data:    settings,
+	})
+}
+
 // SendMessage godoc
 //
-//	@Summary	Send a message to a user (creates/fetches conversation)
-//	@Tags		Chat
-//	@Security	BearerAuth
-//	@Accept		json
-//	@Produce	json
-//	@Param		user_id	path		int					true	"Receiver User ID"
-//	@Param		input	body		models.MessageInput	true	"Message input"
-//	@Success	200		{object}	models.SuccessResponse{data=models.Message}
-//	@Failure	400		{object}	models.ErrorResponse
-//	@Failure	401		{object}	models.ErrorResponse
-//	@Failure	500		{object}	models.ErrorResponse
-//	@Router		/chats/{user_id}/messages [post]
+//	@Summary		Send a message to a user (creates/fetches conversation)
+//	@Description	Accepts a JSON body, or multipart/form-data with a `content` field and up to five `attachments` files (10 MB each). Under the default contact policy employers may only start conversations with their applicants or discoverable candidates, and job seekers may only reply. Employers can send a saved template by passing `template_id` instead of `content`, with `job_id` to fill in {{job_title}}.
+//	@Tags			Chat
+//	@Security		BearerAuth
+//	@Accept			json
+//	@Accept			mpfd
+//	@Produce		json
+//	@Param			user_id	path		int					true	"Receiver User ID"
+//	@Param			input	body		models.MessageInput	true	"Message input"
+//	@Success		200		{object}	models.SuccessResponse{data=models.Message}
+//	@Failure		400		{object}	models.ErrorResponse
+//	@Failure		401		{object}	models.ErrorResponse
+//	@Failure		403		{object}	models.ErrorResponse
+//	@Failure		404		{object}	models.ErrorResponse
+//	@Failure		500		{object}	models.ErrorResponse
+//	@Router			/chats/{user_id}/messages [post]
 func (h *ChatHandler) SendMessage(c *gin.Context) {
 	senderID := c.GetInt("userID")
 	receiverID, err := strconv.Atoi(c.Param("user_id"))
@@ -101,24 +285,94 @@ func (h *ChatHandler) SendMessage(c *gin.Context) {
 		return
 	}
 
-	var input models.MessageInput
-	if err := c.ShouldBindJSON(&input); err != nil || input.Content == "" {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Success: false,
-			Message: "Invalid message input",
-			Error:   &models.ErrorInfo{Code: "VALIDATION_ERROR", Details: err.Error()},
-		})
+	if !h.checkContactPolicy(c, senderID, c.GetString("userRole"), receiverID) {
+		return
+	}
+
+	input, attachments, ok := readMessageInput(c)
+	if !ok {
+		return
+	}
+	if !h.applyTemplate(c, senderID, receiverID, nil, &input) {
+		removeAttachmentFiles(attachments)
+		return
+	}
+
+	h.deliverMessage(c, models.Message{
+		SenderID:    senderID,
+		ReceiverID:  receiverID,
+		Content:     input.Content,
+		Attachments: attachments,
+	})
+}
+
+// SendConversationMessage godoc
+//
+//	@Summary		Send a message in an existing conversation
+//	@Description	Posts to a specific thread, such as one opened from an application. Accepts the same JSON or multipart/form-data body as sending to a user, including `template_id`; {{job_title}} is taken from the conversation's job when it has one.
+//	@Tags			Chat
+//	@Security		BearerAuth
+//	@Accept			json
+//	@Accept			mpfd
+//	@Produce		json
+//	@Param			conversation_id	path		int					true	"Conversation ID"
+//	@Param			input			body		models.MessageInput	true	"Message input"
+//	@Success		200				{object}	models.SuccessResponse{data=models.Message}
+//	@Failure		400				{object}	models.ErrorResponse
+//	@Failure		401				{object}	models.ErrorResponse
+//	@Failure		403				{object}	models.ErrorResponse
+//	@Failure		404				{object}	models.ErrorResponse
+//	@Failure		500				{object}	models.ErrorResponse
+//	@Router			/chats/conversations/{conversation_id}/messages [post]
+func (h *ChatHandler) SendConversationMessage(c *gin.Context) {
+	senderID := c.GetInt("userID")
+
+	conv, ok := h.memberConversation(c, senderID)
+	if !ok {
 		return
 	}
 
-	msg := models.Message{
-		SenderID:   senderID,
-		ReceiverID: receiverID,
-		Content:    input.Content,
+	// Blocks apply between the two people in a one-to-one thread; group members are not checked
+	receiverID := 0
+	if len(conv.ParticipantIDs) == 2 {
+		receiverID = conv.ParticipantIDs[0]
+		if receiverID == senderID {
+			receiverID = conv.ParticipantIDs[1]
+		}
+	}
+
+	input, attachments, ok := readMessageInput(c)
+	if !ok {
+		return
 	}
+	if !h.applyTemplate(c, senderID, receiverID, conv.JobID, &input) {
+		removeAttachmentFiles(attachments)
+		return
+	}
+
+	h.deliverMessage(c, models.Message{
+		ConversationID: conv.ID,
+		SenderID:       senderID,
+		ReceiverID:     receiverID,
+		Content:        input.Content,
+		Attachments:    attachments,
+	})
+}
 
+// deliverMessage saves a message, pushes it to the participants and writes the response
+func (h *ChatHandler) deliverMessage(c *gin.Context, msg models.Message) {
 	msgID, err := h.chatRepo.SaveMessage(msg)
+	if errors.Is(err, repos.ErrBlocked) {
+		removeAttachmentFiles(msg.Attachments)
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Success: false,
+			Message: "You cannot message this user",
+			Error:   &models.ErrorInfo{Code: "USER_BLOCKED"},
+		})
+		return
+	}
 	if err != nil {
+		removeAttachmentFiles(msg.Attachments)
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Success: false,
 			Message: "Failed to send message",
@@ -137,6 +391,8 @@ func (h *ChatHandler) SendMessage(c *gin.Context) {
 		return
 	}
 
+	h.publishMessage(fullMsg)
+
 	c.JSON(http.StatusOK, models.SuccessResponse{
 		Success: true,
 		Message: "Message sent successfully",
@@ -146,16 +402,21 @@ func (h *ChatHandler) SendMessage(c *gin.Context) {
 
 // GetMessages godoc
 //
-//	@Summary	Get messages in a conversation
-//	@Tags		Chat
-//	@Security	BearerAuth
-//	@Produce	json
-//	@Param		conversation_id	path		int	true	"Conversation ID"
-//	@Success	200				{object}	models.SuccessResponse{data=[]models.Message}
-//	@Failure	400				{object}	models.ErrorResponse
-//	@Failure	401				{object}	models.ErrorResponse
-//	@Failure	500				{object}	models.ErrorResponse
-//	@Router		/chats/{conversation_id}/messages [get]
+//	@Summary		Get messages in a conversation
+//	@Description	Returns the most recent messages in ascending order. Pass `next_cursor` as `before` to load older history, or use `after` to fetch newer messages.
+//	@Tags			Chat
+//	@Security		BearerAuth
+//	@Produce		json
+//	@Param			conversation_id	path		int	true	"Conversation ID"
+//	@Param			before			query		int	false	"Return messages with an ID lower than this cursor"
+//	@Param			after			query		int	false	"Return messages with an ID higher than this cursor"
+//	@Param			limit			query		int	false	"Page size (max 100)"	default(50)
+//	@Success		200				{object}	models.SuccessResponse{data=models.MessagePage}
+//	@Failure		400				{object}	models.ErrorResponse
+//	@Failure		401				{object}	models.ErrorResponse
+//	@Failure		404				{object}	models.ErrorResponse
+//	@Failure		500				{object}	models.ErrorResponse
+//	@Router			/chats/{conversation_id}/messages [get]
 func (h *ChatHandler) GetMessages(c *gin.Context) {
 	userID := c.GetInt("userID")
 	convID, err := strconv.Atoi(c.Param("conversation_id"))
@@ -168,7 +429,20 @@ func (h *ChatHandler) GetMessages(c *gin.Context) {
 		return
 	}
 
-	messages, err := h.chatRepo.GetMessagesInConversation(convID, userID)
+	page, ok := bindCursorParams(c)
+	if !ok {
+		return
+	}
+
+	messages, err := h.chatRepo.GetMessagesInConversation(convID, userID, page)
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Success: false,
+			Message: "Conversation not found",
+			Error:   &models.ErrorInfo{Code: "CONVERSATION_NOT_FOUND"},
+		})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Success: false,
@@ -178,6 +452,13 @@ func (h *ChatHandler) GetMessages(c *gin.Context) {
 		return
 	}
 
+	// Fetching the conversation counts as delivery of everything addressed to the caller
+	receipt, err := h.chatRepo.MarkMessagesDeliveredInConversation(convID, userID)
+	if err != nil {
+		log.Printf("GetMessages: %v", err)
+	}
+	h.publishReceipt(realtime.EventMessageDelivered, receipt)
+
 	c.JSON(http.StatusOK, models.SuccessResponse{
 		Success: true,
 		Message: "Messages retrieved successfully",
@@ -187,16 +468,20 @@ func (h *ChatHandler) GetMessages(c *gin.Context) {
 
 // MarkAsRead godoc
 //
-//	@Summary	Mark all messages in a conversation as read
-//	@Tags		Chat
-//	@Security	BearerAuth
-//	@Produce	json
-//	@Param		conversation_id	path		int	true	"Conversation ID"
-//	@Success	200				{object}	models.SuccessResponse
-//	@Failure	400				{object}	models.ErrorResponse
-//	@Failure	401				{object}	models.ErrorResponse
-//	@Failure	500				{object}	models.ErrorResponse
-//	@Router		/chats/{conversation_id}/read [put]
+//	@Summary		Mark messages in a conversation as read
+//	@Description	Marks every message from other participants as read, or only those up to `up_to_message_id`. Senders receive a `message.read` event.
+//	@Tags			Chat
+//	@Security		BearerAuth
+//	@Accept			json
+//	@Produce		json
+//	@Param			conversation_id	path		int						true	"Conversation ID"
+//	@Param			input			body		models.ReadReceiptInput	false	"Read up to a message"
+//	@Success		200				{object}	models.SuccessResponse{data=models.ReceiptUpdate}
+//	@Failure		400				{object}	models.ErrorResponse
+//	@Failure		401				{object}	models.ErrorResponse
+//	@Failure		404				{object}	models.ErrorResponse
+//	@Failure		500				{object}	models.ErrorResponse
+//	@Router			/chats/{conversation_id}/read [put]
 func (h *ChatHandler) MarkAsRead(c *gin.Context) {
 	userID := c.GetInt("userID")
 	convID, err := strconv.Atoi(c.Param("conversation_id"))
@@ -209,7 +494,22 @@ func (h *ChatHandler) MarkAsRead(c *gin.Context) {
 		return
 	}
 
-	if err := h.chatRepo.MarkMessagesAsReadInConversation(convID, userID); err != nil {
+	var input models.ReadReceiptInput
+	if err := c.ShouldBindJSON(&input); (err != nil && !errors.Is(err, io.EOF)) || input.UpToMessageID < 0 {
+		details := "up_to_message_id must not be negative"
+		if err != nil {
+			details = err.Error()
+		}
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Message: "Invalid read receipt input",
+			Error:   &models.ErrorInfo{Code: "VALIDATION_ERROR", Details: details},
+		})
+		return
+	}
+
+	isParticipant, err := h.chatRepo.IsParticipant(convID, userID)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Success: false,
 			Message: "Failed to mark messages as read",
@@ -217,9 +517,253 @@ func (h *ChatHandler) MarkAsRead(c *gin.Context) {
 		})
 		return
 	}
+	if !isParticipant {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Success: false,
+			Message: "Conversation not found",
+			Error:   &models.ErrorInfo{Code: "CONVERSATION_NOT_FOUND"},
+		})
+		return
+	}
+
+	receipt, err := h.chatRepo.MarkMessagesAsReadInConversation(convID, userID, input.UpToMessageID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Message: "Failed to mark messages as read",
+			Error:   &models.ErrorInfo{Code: "DB_ERROR", Details: err.Error()},
+		})
+		return
+	}
+
+	h.publishReceipt(realtime.EventMessageRead, receipt)
 
 	c.JSON(http.StatusOK, models.SuccessResponse{
 		Success: true,
 		Message: "Messages marked as read",
+		Data:    receipt,
+	})
+}
+
+// EditMessage godoc
+//
+//	@Summary		Edit a sent message
+//	@Description	Replaces the text of one of your own messages within the configured edit window. The previous text is kept for moderation.
+//	@Tags			Chat
+//	@Security		BearerAuth
+//	@Accept			json
+//	@Produce		json
+//	@Param			message_id	path		int						true	"Message ID"
+//	@Param			input		body		models.MessageEditInput	true	"New content"
+//	@Success		200			{object}	models.SuccessResponse{data=models.Message}
+//	@Failure		400			{object}	models.ErrorResponse
+//	@Failure		401			{object}	models.ErrorResponse
+//	@Failure		403			{object}	models.ErrorResponse
+//	@Failure		404			{object}	models.ErrorResponse
+//	@Failure		409			{object}	models.ErrorResponse
+//	@Failure		500			{object}	models.ErrorResponse
+//	@Router			/chats/messages/{message_id} [patch]
+func (h *ChatHandler) EditMessage(c *gin.Context) {
+	userID := c.GetInt("userID")
+	messageID, err := strconv.Atoi(c.Param("message_id"))
+	if err != nil || messageID <= 0 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Message: "Invalid message ID",
+			Error:   &models.ErrorInfo{Code: "INVALID_INPUT"},
+		})
+		return
+	}
+
+	var input models.MessageEditInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Message: "Invalid message input",
+			Error:   &models.ErrorInfo{Code: "VALIDATION_ERROR", Details: err.Error()},
+		})
+		return
+	}
+
+	if err := h.chatRepo.EditMessage(messageID, userID, input.Content, h.editWindow); err != nil {
+		writeMessageChangeError(c, err)
+		return
+	}
+
+	msg, err := h.chatRepo.GetMessageByID(messageID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Message: "Failed to fetch message",
+			Error:   &models.ErrorInfo{Code: "DB_ERROR", Details: err.Error()},
+		})
+		return
+	}
+
+	h.publishToConversation(msg.ConversationID, realtime.Event{Type: realtime.EventMessageUpdated, Data: msg})
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Success: true,
+		Message: "Message updated",
+		Data:    msg,
 	})
 }
+
+// DeleteMessage godoc
+//
+//	@Summary		Delete (unsend) a message
+//	@Description	Replaces one of your own messages with a "message deleted" tombstone within the configured edit window. The original text is kept for moderation.
+//	@Tags			Chat
+//	@Security		BearerAuth
+//	@Produce		json
+//	@Param			message_id	path		int	true	"Message ID"
+//	@Success		200			{object}	models.SuccessResponse{data=models.Message}
+//	@Failure		400			{object}	models.ErrorResponse
+//	@Failure		401			{object}	models.ErrorResponse
+//	@Failure		403			{object}	models.ErrorResponse
+//	@Failure		404			{object}	models.ErrorResponse
+//	@Failure		409			{object}	models.ErrorResponse
+//	@Failure		500			{object}	models.ErrorResponse
+//	@Router			/chats/messages/{message_id} [delete]
+func (h *ChatHandler) DeleteMessage(c *gin.Context) {
+	userID := c.GetInt("userID")
+	messageID, err := strconv.Atoi(c.Param("message_id"))
+	if err != nil || messageID <= 0 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Message: "Invalid message ID",
+			Error:   &models.ErrorInfo{Code: "INVALID_INPUT"},
+		})
+		return
+	}
+
+	if err := h.chatRepo.DeleteMessage(messageID, userID, h.editWindow); err != nil {
+		writeMessageChangeError(c, err)
+		return
+	}
+
+	msg, err := h.chatRepo.GetMessageByID(messageID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Message: "Failed to fetch message",
+			Error:   &models.ErrorInfo{Code: "DB_ERROR", Details: err.Error()},
+		})
+		return
+	}
+
+	h.publishToConversation(msg.ConversationID, realtime.Event{Type: realtime.EventMessageDeleted, Data: msg})
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Success: true,
+		Message: "Message deleted",
+		Data:    msg,
+	})
+}
+
+// GetMessageRevisions godoc
+//
+//	@Summary		Get the edit history of a message
+//	@Description	Lists the previous versions of an edited or deleted message. Requires role: admin
+//	@Tags			Chat
+//	@Security		BearerAuth
+//	@Produce		json
+//	@Param			message_id	path		int	true	"Message ID"
+//	@Success		200			{object}	models.SuccessResponse{data=[]models.MessageRevision}
+//	@Failure		400			{object}	models.ErrorResponse
+//	@Failure		401			{object}	models.ErrorResponse
+//	@Failure		403			{object}	models.ErrorResponse
+//	@Failure		500			{object}	models.ErrorResponse
+//	@Router			/chats/messages/{message_id}/revisions [get]
+func (h *ChatHandler) GetMessageRevisions(c *gin.Context) {
+	messageID, err := strconv.Atoi(c.Param("message_id"))
+	if err != nil || messageID <= 0 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Message: "Invalid message ID",
+			Error:   &models.ErrorInfo{Code: "INVALID_INPUT"},
+		})
+		return
+	}
+
+	revisions, err := h.chatRepo.GetMessageRevisions(messageID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Message: "Failed to retrieve revisions",
+			Error:   &models.ErrorInfo{Code: "DB_ERROR", Details: err.Error()},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Success: true,
+		Message: "Revisions retrieved",
+		Data:    revisions,
+	})
+}
+
+// writeMessageChangeError maps edit/delete failures to responses
+func writeMessageChangeError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repos.ErrMessageNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Success: false,
+			Message: "Message not found",
+			Error:   &models.ErrorInfo{Code: "MESSAGE_NOT_FOUND"},
+		})
+	case errors.Is(err, repos.ErrNotMessageSender):
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Success: false,
+			Message: "Only the sender can change this message",
+			Error:   &models.ErrorInfo{Code: "FORBIDDEN"},
+		})
+	case errors.Is(err, repos.ErrEditWindowExpired):
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Success: false,
+			Message: "This message can no longer be changed",
+			Error:   &models.ErrorInfo{Code: "EDIT_WINDOW_EXPIRED"},
+		})
+	case errors.Is(err, repos.ErrMessageDeleted):
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Success: false,
+			Message: "Message has already been deleted",
+			Error:   &models.ErrorInfo{Code: "MESSAGE_DELETED"},
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Message: "Failed to update message",
+			Error:   &models.ErrorInfo{Code: "DB_ERROR", Details: err.Error()},
+		})
+	}
+}
+
+// bindCursorParams reads before/after/limit query parameters, writing a 400 response when they are invalid
+func bindCursorParams(c *gin.Context) (models.CursorParams, bool) {
+	var page models.CursorParams
+	if err := c.ShouldBindQuery(&page); err != nil || page.Before < 0 || page.After < 0 {
+		details := "before and after must be positive IDs"
+		if err != nil {
+			details = err.Error()
+		}
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Message: "Invalid pagination parameters",
+			Error:   &models.ErrorInfo{Code: "INVALID_PARAMS", Details: details},
+		})
+		return page, false
+	}
+	if page.Before > 0 && page.After > 0 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Message: "Use either before or after, not both",
+			Error:   &models.ErrorInfo{Code: "INVALID_PARAMS"},
+		})
+		return page, false
+	}
+	if page.Limit < 1 || page.Limit > 100 {
+		page.Limit = 50
+	}
+	return page, true
+}
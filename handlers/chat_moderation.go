@@ -0,0 +1,400 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/XORbit01/jobseeker-backend/models"
+	"github.com/XORbit01/jobseeker-backend/repos"
+	"github.com/gin-gonic/gin"
+)
+
+// BlockUser godoc
+//
+//	@Summary		Block a user
+//	@Description	Stops the user from messaging you. You also cannot message them until you unblock them.
+//	@Tags			Chat
+//	@Security		BearerAuth
+//	@Produce		json
+//	@Param			user_id	path		int	true	"ID of the user to block"
+//	@Success		200		{object}	models.SuccessResponse
+//	@Failure		400		{object}	models.ErrorResponse
+//	@Failure		401		{object}	models.ErrorResponse
+//	@Failure		404		{object}	models.ErrorResponse
+//	@Failure		500		{object}	models.ErrorResponse
+//	@Router			/chats/blocks/{user_id} [post]
+func (h *ChatHandler) BlockUser(c *gin.Context) {
+	userID := c.GetInt("userID")
+	blockedID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil || blockedID <= 0 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Message: "Invalid user ID",
+			Error:   &models.ErrorInfo{Code: "INVALID_INPUT"},
+		})
+		return
+	}
+	if blockedID == userID {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Message: "You cannot block yourself",
+			Error:   &models.ErrorInfo{Code: "INVALID_INPUT"},
+		})
+		return
+	}
+
+	err = h.moderationRepo.BlockUser(userID, blockedID)
+	if errors.Is(err, repos.ErrUserNotFound) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Success: false,
+			Message: "User not found",
+			Error:   &models.ErrorInfo{Code: "USER_NOT_FOUND"},
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Message: "Failed to block user",
+			Error:   &models.ErrorInfo{Code: "DB_ERROR", Details: err.Error()},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Success: true,
+		Message: "User blocked",
+	})
+}
+
+// UnblockUser godoc
+//
+//	@Summary		Unblock a user
+//	@Description	Removes the user from your block list
+//	@Tags			Chat
+//	@Security		BearerAuth
+//	@Produce		json
+//	@Param			user_id	path		int	true	"ID of the user to unblock"
+//	@Success		200		{object}	models.SuccessResponse
+//	@Failure		400		{object}	models.ErrorResponse
+//	@Failure		401		{object}	models.ErrorResponse
+//	@Failure		500		{object}	models.ErrorResponse
+//	@Router			/chats/blocks/{user_id} [delete]
+func (h *ChatHandler) UnblockUser(c *gin.Context) {
+	userID := c.GetInt("userID")
+	blockedID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil || blockedID <= 0 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Message: "Invalid user ID",
+			Error:   &models.ErrorInfo{Code: "INVALID_INPUT"},
+		})
+		return
+	}
+
+	if err := h.moderationRepo.UnblockUser(userID, blockedID); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Message: "Failed to unblock user",
+			Error:   &models.ErrorInfo{Code: "DB_ERROR", Details: err.Error()},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Success: true,
+		Message: "User unblocked",
+	})
+}
+
+// GetBlockedUsers godoc
+//
+//	@Summary		List blocked users
+//	@Description	Returns the users you have blocked, most recent first
+//	@Tags			Chat
+//	@Security		BearerAuth
+//	@Produce		json
+//	@Success		200	{object}	models.SuccessResponse{data=[]models.UserBlock}
+//	@Failure		401	{object}	models.ErrorResponse
+//	@Failure		500	{object}	models.ErrorResponse
+//	@Router			/chats/blocks [get]
+func (h *ChatHandler) GetBlockedUsers(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	blocks, err := h.moderationRepo.GetBlockedUsers(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Message: "Failed to retrieve blocked users",
+			Error:   &models.ErrorInfo{Code: "DB_ERROR", Details: err.Error()},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Success: true,
+		Message: "Blocked users retrieved",
+		Data:    blocks,
+	})
+}
+
+// ReportUser godoc
+//
+//	@Summary		Report abuse
+//	@Description	Flags a user to moderators, optionally pointing at the conversation or message that shows the abuse. You must take part in the referenced conversation.
+//	@Tags			Chat
+//	@Security		BearerAuth
+//	@Accept			json
+//	@Produce		json
+//	@Param			input	body		models.ReportInput	true	"Report details"
+//	@Success		201		{object}	models.SuccessResponse{data=models.MessageReport}
+//	@Failure		400		{object}	models.ErrorResponse
+//	@Failure		401		{object}	models.ErrorResponse
+//	@Failure		404		{object}	models.ErrorResponse
+//	@Failure		500		{object}	models.ErrorResponse
+//	@Router			/chats/reports [post]
+func (h *ChatHandler) ReportUser(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	var input models.ReportInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Message: "Invalid report input",
+			Error:   &models.ErrorInfo{Code: "VALIDATION_ERROR", Details: err.Error()},
+		})
+		return
+	}
+	if input.ReportedUserID == userID {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Message: "You cannot report yourself",
+			Error:   &models.ErrorInfo{Code: "INVALID_INPUT"},
+		})
+		return
+	}
+
+	// A reported message pins down the conversation it was sent in. Messages in conversations
+	// the reporter is not part of are treated as missing, so their existence is not revealed.
+	if input.MessageID != nil {
+		msg, err := h.chatRepo.GetMessageByID(*input.MessageID)
+		isMember := false
+		if err == nil && msg != nil {
+			isMember, err = h.chatRepo.IsParticipant(msg.ConversationID, userID)
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Success: false,
+				Message: "Failed to fetch message",
+				Error:   &models.ErrorInfo{Code: "DB_ERROR", Details: err.Error()},
+			})
+			return
+		}
+		if !isMember {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Success: false,
+				Message: "Message not found",
+				Error:   &models.ErrorInfo{Code: "MESSAGE_NOT_FOUND"},
+			})
+			return
+		}
+		if msg.SenderID != input.ReportedUserID ||
+			(input.ConversationID != nil && *input.ConversationID != msg.ConversationID) {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Success: false,
+				Message: "The message was not sent by the reported user in this conversation",
+				Error:   &models.ErrorInfo{Code: "VALIDATION_ERROR"},
+			})
+			return
+		}
+		input.ConversationID = &msg.ConversationID
+	}
+
+	if input.ConversationID != nil {
+		participants, err := h.chatRepo.GetParticipantIDs(*input.ConversationID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Success: false,
+				Message: "Failed to fetch conversation",
+				Error:   &models.ErrorInfo{Code: "DB_ERROR", Details: err.Error()},
+			})
+			return
+		}
+		if !slices.Contains(participants, userID) || !slices.Contains(participants, input.ReportedUserID) {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Success: false,
+				Message: "Conversation not found",
+				Error:   &models.ErrorInfo{Code: "CONVERSATION_NOT_FOUND"},
+			})
+			return
+		}
+	}
+
+	reportID, err := h.moderationRepo.CreateReport(userID, input)
+	if errors.Is(err, repos.ErrUserNotFound) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Success: false,
+			Message: "User not found",
+			Error:   &models.ErrorInfo{Code: "USER_NOT_FOUND"},
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Message: "Failed to submit report",
+			Error:   &models.ErrorInfo{Code: "DB_ERROR", Details: err.Error()},
+		})
+		return
+	}
+
+	report, err := h.moderationRepo.GetReportByID(reportID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Message: "Failed to fetch report",
+			Error:   &models.ErrorInfo{Code: "DB_ERROR", Details: err.Error()},
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.SuccessResponse{
+		Success: true,
+		Message: "Report submitted",
+		Data:    report,
+	})
+}
+
+// GetReports godoc
+//
+//	@Summary		List abuse reports
+//	@Description	Returns chat abuse reports, newest first. Requires role: admin
+//	@Tags			Chat
+//	@Security		BearerAuth
+//	@Produce		json
+//	@Param			status	query		string	false	"Filter by status"	Enums(open, resolved, dismissed)
+//	@Param			page	query		int		false	"Page number"		default(1)
+//	@Param			limit	query		int		false	"Results per page"	default(10)
+//	@Success		200		{object}	models.PaginatedResponse{data=[]models.MessageReport}
+//	@Failure		400		{object}	models.ErrorResponse
+//	@Failure		401		{object}	models.ErrorResponse
+//	@Failure		403		{object}	models.ErrorResponse
+//	@Failure		500		{object}	models.ErrorResponse
+//	@Router			/chats/reports [get]
+func (h *ChatHandler) GetReports(c *gin.Context) {
+	status := c.Query("status")
+	if status != "" && !slices.Contains([]string{"open", "resolved", "dismissed"}, status) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Message: "Invalid status filter",
+			Error:   &models.ErrorInfo{Code: "INVALID_PARAMS"},
+		})
+		return
+	}
+
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	reports, total, err := h.moderationRepo.GetReports(status, page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Message: "Failed to retrieve reports",
+			Error:   &models.ErrorInfo{Code: "DB_ERROR", Details: err.Error()},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.PaginatedResponse{
+		Success:    true,
+		Message:    "Reports retrieved",
+		Data:       reports,
+		Page:       page,
+		TotalPages: (total + limit - 1) / limit,
+		TotalItems: total,
+		Limit:      limit,
+	})
+}
+
+// UpdateReportStatus godoc
+//
+//	@Summary		Review an abuse report
+//	@Description	Marks a report as resolved or dismissed, or reopens it. Requires role: admin
+//	@Tags			Chat
+//	@Security		BearerAuth
+//	@Accept			json
+//	@Produce		json
+//	@Param			report_id	path		int							true	"Report ID"
+//	@Param			input		body		models.ReportStatusInput	true	"New status"
+//	@Success		200			{object}	models.SuccessResponse{data=models.MessageReport}
+//	@Failure		400			{object}	models.ErrorResponse
+//	@Failure		401			{object}	models.ErrorResponse
+//	@Failure		403			{object}	models.ErrorResponse
+//	@Failure		404			{object}	models.ErrorResponse
+//	@Failure		500			{object}	models.ErrorResponse
+//	@Router			/chats/reports/{report_id} [put]
+func (h *ChatHandler) UpdateReportStatus(c *gin.Context) {
+	reviewerID := c.GetInt("userID")
+	reportID, err := strconv.Atoi(c.Param("report_id"))
+	if err != nil || reportID <= 0 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Message: "Invalid report ID",
+			Error:   &models.ErrorInfo{Code: "INVALID_INPUT"},
+		})
+		return
+	}
+
+	var input models.ReportStatusInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Message: "Invalid status",
+			Error:   &models.ErrorInfo{Code: "VALIDATION_ERROR", Details: err.Error()},
+		})
+		return
+	}
+
+	err = h.moderationRepo.UpdateReportStatus(reportID, reviewerID, input.Status)
+	if errors.Is(err, repos.ErrReportNotFound) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Success: false,
+			Message: "Report not found",
+			Error:   &models.ErrorInfo{Code: "REPORT_NOT_FOUND"},
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Message: "Failed to update report",
+			Error:   &models.ErrorInfo{Code: "DB_ERROR", Details: err.Error()},
+		})
+		return
+	}
+
+	report, err := h.moderationRepo.GetReportByID(reportID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Message: "Failed to fetch report",
+			Error:   &models.ErrorInfo{Code: "DB_ERROR", Details: err.Error()},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Success: true,
+		Message: "Report updated",
+		Data:    report,
+	})
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/XORbit01/jobseeker-backend/config"
+	"github.com/XORbit01/jobseeker-backend/models"
+	"github.com/XORbit01/jobseeker-backend/repos"
+	"github.com/gin-gonic/gin"
+)
+
+// checkContactPolicy reports whether the sender may message the receiver.
+// When they may not, it writes the error response and returns false.
+func (h *ChatHandler) checkContactPolicy(c *gin.Context, senderID int, senderRole string, receiverID int) bool {
+	if senderID == receiverID {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Message: "You cannot message yourself",
+			Error:   &models.ErrorInfo{Code: "INVALID_INPUT"},
+		})
+		return false
+	}
+
+	receiverRole, err := h.chatRepo.GetUserRole(receiverID)
+	if errors.Is(err, repos.ErrUserNotFound) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Success: false,
+			Message: "User not found",
+			Error:   &models.ErrorInfo{Code: "USER_NOT_FOUND"},
+		})
+		return false
+	}
+	if err != nil {
+		writeContactPolicyError(c, err)
+		return false
+	}
+
+	allowed, message, err := h.contactAllowed(senderID, senderRole, receiverID, receiverRole)
+	if err != nil {
+		writeContactPolicyError(c, err)
+		return false
+	}
+	if !allowed {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Success: false,
+			Message: message,
+			Error:   &models.ErrorInfo{Code: "FORBIDDEN_CONTACT"},
+		})
+		return false
+	}
+	return true
+}
+
+// checkGroupMembers reports whether the actor may add the given users to a conversation.
+// Employers may always bring in other employers and admins, so hiring teams can share a thread.
+// When a user may not be added, it writes the error response and returns false.
+func (h *ChatHandler) checkGroupMembers(c *gin.Context, actorID int, actorRole string, userIDs []int) bool {
+	for _, id := range userIDs {
+		if id == actorID {
+			continue
+		}
+
+		role, err := h.chatRepo.GetUserRole(id)
+		if errors.Is(err, repos.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Success: false,
+				Message: "User not found",
+				Error:   &models.ErrorInfo{Code: "USER_NOT_FOUND", Details: fmt.Sprintf("user %d", id)},
+			})
+			return false
+		}
+		if err != nil {
+			writeContactPolicyError(c, err)
+			return false
+		}
+
+		blocked, err := h.chatRepo.IsBlockedBetween(actorID, id)
+		if err != nil {
+			writeContactPolicyError(c, err)
+			return false
+		}
+		if blocked {
+			c.JSON(http.StatusForbidden, models.ErrorResponse{
+				Success: false,
+				Message: "You cannot add this user",
+				Error:   &models.ErrorInfo{Code: "USER_BLOCKED", Details: fmt.Sprintf("user %d", id)},
+			})
+			return false
+		}
+
+		if actorRole == "employer" && (role == "employer" || role == "admin") {
+			continue
+		}
+
+		allowed, message, err := h.contactAllowed(actorID, actorRole, id, role)
+		if err != nil {
+			writeContactPolicyError(c, err)
+			return false
+		}
+		if !allowed {
+			c.JSON(http.StatusForbidden, models.ErrorResponse{
+				Success: false,
+				Message: message,
+				Error:   &models.ErrorInfo{Code: "FORBIDDEN_CONTACT", Details: fmt.Sprintf("user %d", id)},
+			})
+			return false
+		}
+	}
+	return true
+}
+
+// contactAllowed applies the contact policy to an existing receiver.
+// When contact is not allowed it also returns the reason to show to the sender.
+func (h *ChatHandler) contactAllowed(senderID int, senderRole string, receiverID int, receiverRole string) (bool, string, error) {
+	if h.contactPolicy == config.ContactPolicyOpen || senderRole == "admin" {
+		return true, "", nil
+	}
+
+	// Anyone may keep talking in a conversation that already exists
+	replying, err := h.chatRepo.HasConversationBetween(senderID, receiverID)
+	if err != nil {
+		return false, "", err
+	}
+	if replying {
+		return true, "", nil
+	}
+
+	switch {
+	case senderRole == "employer" && receiverRole == "job_seeker":
+		allowed, err := h.chatRepo.CanEmployerContact(senderID, receiverID)
+		if err != nil || allowed {
+			return allowed, "", err
+		}
+		return false, "Employers can only message candidates who applied to their jobs or are open to contact", nil
+	case senderRole == "job_seeker":
+		return false, "Job seekers can only reply to conversations started by an employer", nil
+	}
+	return false, "You are not allowed to start a conversation with this user", nil
+}
+
+func writeContactPolicyError(c *gin.Context, err error) {
+	c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+		Success: false,
+		Message: "Failed to check contact permissions",
+		Error:   &models.ErrorInfo{Code: "DB_ERROR", Details: err.Error()},
+	})
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/XORbit01/jobseeker-backend/models"
+	"github.com/gin-gonic/gin"
+)
+
+// SearchMessages godoc
+//
+//	@Summary		Search your messages
+//	@Description	Full-text search over the messages of every conversation you take part in. Supports quoted phrases, `or` and `-word` exclusions. Results are ordered by relevance and include a snippet with the matched words wrapped in `<mark>` tags.
+//	@Tags			Chat
+//	@Security		BearerAuth
+//	@Produce		json
+//	@Param			q				query		string	true	"Search terms"
+//	@Param			conversation_id	query		int		false	"Only search this conversation"
+//	@Param			page			query		int		false	"Page number"
+//	@Param			limit			query		int		false	"Results per page (max 100)"
+//	@Success		200				{object}	models.PaginatedResponse{data=[]models.MessageSearchResult}
+//	@Failure		400				{object}	models.ErrorResponse
+//	@Failure		401				{object}	models.ErrorResponse
+//	@Failure		500				{object}	models.ErrorResponse
+//	@Router			/chats/search [get]
+func (h *ChatHandler) SearchMessages(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	var params models.MessageSearchParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Message: "Invalid search parameters",
+			Error:   &models.ErrorInfo{Code: "INVALID_PARAMS", Details: err.Error()},
+		})
+		return
+	}
+	params.Query = strings.TrimSpace(params.Query)
+	if params.Query == "" || params.ConversationID < 0 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Message: "Invalid search parameters",
+			Error:   &models.ErrorInfo{Code: "INVALID_PARAMS", Details: "q must not be blank and conversation_id must be a positive ID"},
+		})
+		return
+	}
+
+	if params.Page < 1 {
+		params.Page = 1
+	}
+	if params.Limit < 1 || params.Limit > 100 {
+		params.Limit = 20
+	}
+
+	results, total, err := h.chatRepo.SearchMessages(userID, params)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Message: "Failed to search messages",
+			Error:   &models.ErrorInfo{Code: "SEARCH_FAILED", Details: err.Error()},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.PaginatedResponse{
+		Success:    true,
+		Message:    "Messages retrieved successfully",
+		Data:       results,
+		Page:       params.Page,
+		TotalPages: (total + params.Limit - 1) / params.Limit,
+		TotalItems: total,
+		Limit:      params.Limit,
+	})
+}
@@ -0,0 +1,319 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/XORbit01/jobseeker-backend/models"
+	"github.com/XORbit01/jobseeker-backend/repos"
+	"github.com/gin-gonic/gin"
+)
+
+// templateVariablePattern matches placeholders such as {{first_name}} or {{ job_title }}
+var templateVariablePattern = regexp.MustCompile(`\{\{\s*([a-zA-Z_]+)\s*\}\}`)
+
+var templateVariables = []string{
+	models.TemplateVarFirstName,
+	models.TemplateVarJobTitle,
+	models.TemplateVarCompanyName,
+}
+
+// GetTemplates godoc
+//
+//	@Summary		List your message templates
+//	@Description	Returns the employer's saved messages, sorted by name. Requires role: employer
+//	@Tags			Chat
+//	@Security		BearerAuth
+//	@Produce		json
+//	@Success		200	{object}	models.SuccessResponse{data=[]models.MessageTemplate}
+//	@Failure		401	{object}	models.ErrorResponse
+//	@Failure		403	{object}	models.ErrorResponse
+//	@Failure		500	{object}	models.ErrorResponse
+//	@Router			/chats/templates [get]
+func (h *ChatHandler) GetTemplates(c *gin.Context) {
+	templates, err := h.templateRepo.GetByEmployer(c.GetInt("userID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Message: "Failed to retrieve templates",
+			Error:   &models.ErrorInfo{Code: "DB_ERROR", Details: err.Error()},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Success: true,
+		Message: "Templates retrieved",
+		Data:    templates,
+	})
+}
+
+// CreateTemplate godoc
+//
+//	@Summary		Create a message template
+//	@Description	Saves a reusable message. The body may contain {{first_name}}, {{job_title}} and {{company_name}}, which are filled in when the template is sent. Requires role: employer
+//	@Tags			Chat
+//	@Security		BearerAuth
+//	@Accept			json
+//	@Produce		json
+//	@Param			input	body		models.MessageTemplateInput	true	"Template"
+//	@Success		201		{object}	models.SuccessResponse{data=models.MessageTemplate}
+//	@Failure		400		{object}	models.ErrorResponse
+//	@Failure		401		{object}	models.ErrorResponse
+//	@Failure		403		{object}	models.ErrorResponse
+//	@Failure		409		{object}	models.ErrorResponse
+//	@Failure		500		{object}	models.ErrorResponse
+//	@Router			/chats/templates [post]
+func (h *ChatHandler) CreateTemplate(c *gin.Context) {
+	input, ok := bindTemplateInput(c)
+	if !ok {
+		return
+	}
+
+	template, err := h.templateRepo.Create(c.GetInt("userID"), input)
+	if err != nil {
+		writeTemplateError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.SuccessResponse{
+		Success: true,
+		Message: "Template created",
+		Data:    template,
+	})
+}
+
+// UpdateTemplate godoc
+//
+//	@Summary		Update a message template
+//	@Description	Replaces the name and body of one of your templates. Requires role: employer
+//	@Tags			Chat
+//	@Security		BearerAuth
+//	@Accept			json
+//	@Produce		json
+//	@Param			template_id	path		int							true	"Template ID"
+//	@Param			input		body		models.MessageTemplateInput	true	"Template"
+//	@Success		200			{object}	models.SuccessResponse{data=models.MessageTemplate}
+//	@Failure		400			{object}	models.ErrorResponse
+//	@Failure		401			{object}	models.ErrorResponse
+//	@Failure		403			{object}	models.ErrorResponse
+//	@Failure		404			{object}	models.ErrorResponse
+//	@Failure		409			{object}	models.ErrorResponse
+//	@Failure		500			{object}	models.ErrorResponse
+//	@Router			/chats/templates/{template_id} [put]
+func (h *ChatHandler) UpdateTemplate(c *gin.Context) {
+	templateID, ok := templateIDParam(c)
+	if !ok {
+		return
+	}
+	input, ok := bindTemplateInput(c)
+	if !ok {
+		return
+	}
+
+	template, err := h.templateRepo.Update(templateID, c.GetInt("userID"), input)
+	if err != nil {
+		writeTemplateError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Success: true,
+		Message: "Template updated",
+		Data:    template,
+	})
+}
+
+// DeleteTemplate godoc
+//
+//	@Summary		Delete a message template
+//	@Description	Requires role: employer
+//	@Tags			Chat
+//	@Security		BearerAuth
+//	@Produce		json
+//	@Param			template_id	path		int	true	"Template ID"
+//	@Success		200			{object}	models.SuccessResponse
+//	@Failure		400			{object}	models.ErrorResponse
+//	@Failure		401			{object}	models.ErrorResponse
+//	@Failure		403			{object}	models.ErrorResponse
+//	@Failure		404			{object}	models.ErrorResponse
+//	@Failure		500			{object}	models.ErrorResponse
+//	@Router			/chats/templates/{template_id} [delete]
+func (h *ChatHandler) DeleteTemplate(c *gin.Context) {
+	templateID, ok := templateIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.templateRepo.Delete(templateID, c.GetInt("userID")); err != nil {
+		writeTemplateError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Success: true,
+		Message: "Template deleted",
+	})
+}
+
+// applyTemplate replaces the message content with the sender's template, filling in its variables.
+// The candidate is the receiver and the job comes from the conversation or, failing that, from
+// input.JobID. When it fails it writes the error response and returns false.
+func (h *ChatHandler) applyTemplate(c *gin.Context, senderID, receiverID int, conversationJobID *int, input *models.MessageInput) bool {
+	if input.TemplateID == 0 {
+		return true
+	}
+	if strings.TrimSpace(input.Content) != "" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Message: "Send either content or a template, not both",
+			Error:   &models.ErrorInfo{Code: "VALIDATION_ERROR"},
+		})
+		return false
+	}
+
+	template, err := h.templateRepo.GetByID(input.TemplateID, senderID)
+	if err != nil {
+		writeTemplateError(c, err)
+		return false
+	}
+
+	content, missing, err := h.fillTemplate(template.Body, senderID, receiverID, conversationJobID, input.JobID)
+	if err != nil {
+		writeTemplateError(c, err)
+		return false
+	}
+	if len(missing) > 0 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Message: "Some template variables could not be filled in for this conversation",
+			Error:   &models.ErrorInfo{Code: "TEMPLATE_VARIABLE_MISSING", Details: strings.Join(missing, ", ")},
+		})
+		return false
+	}
+	input.Content = content
+	return true
+}
+
+// fillTemplate resolves the variables used in a template body. It returns the names of variables
+// that have no value, for example {{first_name}} when the receiver has no job seeker profile.
+func (h *ChatHandler) fillTemplate(body string, senderID, receiverID int, conversationJobID *int, jobID int) (string, []string, error) {
+	values := make(map[string]string)
+	var err error
+	for _, name := range templateVariableNames(body) {
+		switch name {
+		case models.TemplateVarFirstName:
+			if receiverID != 0 {
+				values[name], err = h.templateRepo.GetCandidateFirstName(receiverID)
+			}
+		case models.TemplateVarCompanyName:
+			values[name], err = h.templateRepo.GetCompanyName(senderID)
+		case models.TemplateVarJobTitle:
+			switch {
+			case conversationJobID != nil:
+				values[name], err = h.templateRepo.GetJobTitle(*conversationJobID, 0)
+			case jobID != 0:
+				values[name], err = h.templateRepo.GetJobTitle(jobID, senderID)
+			}
+		}
+		if err != nil {
+			return "", nil, err
+		}
+	}
+
+	var missing []string
+	content := templateVariablePattern.ReplaceAllStringFunc(body, func(placeholder string) string {
+		name := templateVariablePattern.FindStringSubmatch(placeholder)[1]
+		value := values[name]
+		if value == "" && !slices.Contains(missing, name) {
+			missing = append(missing, name)
+		}
+		return value
+	})
+	return content, missing, nil
+}
+
+// templateVariableNames lists the distinct placeholder names used in a template body
+func templateVariableNames(body string) []string {
+	var names []string
+	for _, match := range templateVariablePattern.FindAllStringSubmatch(body, -1) {
+		if !slices.Contains(names, match[1]) {
+			names = append(names, match[1])
+		}
+	}
+	return names
+}
+
+// bindTemplateInput reads a template body and rejects unknown placeholders, writing a 400 response on failure
+func bindTemplateInput(c *gin.Context) (models.MessageTemplateInput, bool) {
+	var input models.MessageTemplateInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Message: "Invalid template input",
+			Error:   &models.ErrorInfo{Code: "VALIDATION_ERROR", Details: err.Error()},
+		})
+		return input, false
+	}
+
+	return input, checkTemplateVariables(c, input.Body)
+}
+
+// checkTemplateVariables rejects placeholders other than the supported variables,
+// writing a 400 response when it finds one
+func checkTemplateVariables(c *gin.Context, body string) bool {
+	for _, name := range templateVariableNames(body) {
+		if !slices.Contains(templateVariables, name) {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Success: false,
+				Message: "Unknown template variable {{" + name + "}}",
+				Error: &models.ErrorInfo{
+					Code:    "UNKNOWN_TEMPLATE_VARIABLE",
+					Details: "supported variables: " + strings.Join(templateVariables, ", "),
+				},
+			})
+			return false
+		}
+	}
+	return true
+}
+
+func templateIDParam(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("template_id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Message: "Invalid template ID",
+			Error:   &models.ErrorInfo{Code: "INVALID_INPUT"},
+		})
+		return 0, false
+	}
+	return id, true
+}
+
+func writeTemplateError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repos.ErrTemplateNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Success: false,
+			Message: "Template not found",
+			Error:   &models.ErrorInfo{Code: "TEMPLATE_NOT_FOUND"},
+		})
+	case errors.Is(err, repos.ErrDuplicateTemplateName):
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Success: false,
+			Message: "You already have a template with this name",
+			Error:   &models.ErrorInfo{Code: "DUPLICATE_TEMPLATE"},
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Message: "Failed to process template",
+			Error:   &models.ErrorInfo{Code: "DB_ERROR", Details: err.Error()},
+		})
+	}
+}
@@ -0,0 +1,240 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/XORbit01/jobseeker-backend/models"
+	"github.com/XORbit01/jobseeker-backend/realtime"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// Time allowed to write a frame to the peer
+	wsWriteWait = 10 * time.Second
+	// Time allowed to read the next pong from the peer
+	wsPongWait = 60 * time.Second
+	// Send pings with this period, must be less than wsPongWait
+	wsPingPeriod = (wsPongWait * 9) / 10
+	// Maximum size of a frame sent by the peer
+	wsMaxMessageSize = 4096
+)
+
+var wsUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	// Authentication uses the ?token= query parameter rather than cookies,
+	// so a foreign origin cannot ride on an existing browser session.
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// ServeWS godoc
+//
+//	@Summary		Open a real-time chat connection
+//	@Description	Upgrades to a WebSocket. Pass the JWT as `?token=`. The server pushes `{"id": 1, "type": "...", "data": {...}}` events such as `message.new`, `message.delivered`, `message.read`, `application.status`, `typing` and `presence`. Use GET /events where WebSockets are blocked. Clients may send `{"type": "typing", "conversation_id": 1, "is_typing": true}`.
+//	@Tags			Chat
+//	@Security		BearerAuth
+//	@Param			token	query	string	false	"JWT access token (browsers cannot set headers on WebSocket upgrades)"
+//	@Success		101
+//	@Failure		401	{object}	models.ErrorResponse
+//	@Router			/chats/ws [get]
+func (h *ChatHandler) ServeWS(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	conn, err := wsUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// Upgrade already wrote an HTTP error to the client
+		log.Printf("websocket upgrade failed for user %d: %v", userID, err)
+		return
+	}
+
+	client := h.hub.Register(userID, c.GetInt("sessionID"))
+	go h.wsWritePump(conn, client)
+	h.wsReadPump(conn, client)
+}
+
+// wsReadPump keeps the read side of the connection alive and detects disconnects
+func (h *ChatHandler) wsReadPump(conn *websocket.Conn, client *realtime.Client) {
+	defer func() {
+		h.hub.Unregister(client)
+		conn.Close()
+	}()
+
+	conn.SetReadLimit(wsMaxMessageSize)
+	conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+
+	for {
+		_, payload, err := conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
+				log.Printf("websocket read error for user %d: %v", client.UserID, err)
+			}
+			return
+		}
+
+		var frame models.ChatClientEvent
+		if err := json.Unmarshal(payload, &frame); err != nil {
+			// Ignore malformed frames instead of dropping the connection
+			continue
+		}
+
+		switch frame.Type {
+		case realtime.EventTyping:
+			h.relayTyping(client.UserID, frame)
+		}
+	}
+}
+
+// wsWritePump forwards hub events to the connection and sends keepalive pings.
+// The connection is closed once its login session is logged out or revoked.
+func (h *ChatHandler) wsWritePump(conn *websocket.Conn, client *realtime.Client) {
+	ticker := time.NewTicker(wsPingPeriod)
+	defer func() {
+		ticker.Stop()
+		conn.Close()
+	}()
+
+	for {
+		select {
+		case evt, ok := <-client.Send:
+			conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if !ok {
+				// Hub closed the channel
+				conn.WriteMessage(websocket.CloseMessage, []byte{})
+				return
+			}
+			if err := conn.WriteJSON(evt); err != nil {
+				return
+			}
+		case <-ticker.C:
+			conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if sessionRevoked(h.sessionRepo, client) {
+				conn.WriteMessage(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "session has been logged out"))
+				return
+			}
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// relayTyping forwards a typing indicator to the other participants of the conversation.
+// Typing state is ephemeral and never stored.
+func (h *ChatHandler) relayTyping(userID int, frame models.ChatClientEvent) {
+	participants, err := h.chatRepo.GetParticipantIDs(frame.ConversationID)
+	if err != nil {
+		return
+	}
+	if !slices.Contains(participants, userID) {
+		return
+	}
+
+	others := slices.DeleteFunc(participants, func(id int) bool { return id == userID })
+	h.hub.SendToUsers(others, realtime.Event{
+		Type: realtime.EventTyping,
+		Data: models.TypingIndicator{
+			ConversationID: frame.ConversationID,
+			UserID:         userID,
+			IsTyping:       frame.IsTyping,
+		},
+	})
+}
+
+// announcePresence tells everyone sharing a conversation with the user that they went online or offline
+func (h *ChatHandler) announcePresence(userID int, online bool) {
+	presence := models.Presence{UserID: userID, Online: online}
+	if !online {
+		now := time.Now()
+		if err := h.chatRepo.UpdateLastSeen(userID, now); err != nil {
+			log.Printf("announcePresence: %v", err)
+		}
+		presence.LastSeenAt = &now
+	}
+
+	contacts, err := h.chatRepo.GetContactIDs(userID)
+	if err != nil {
+		log.Printf("announcePresence: %v", err)
+		return
+	}
+
+	h.hub.SendToUsers(contacts, realtime.Event{
+		Type: realtime.EventPresence,
+		Data: presence,
+	})
+}
+
+// publishMessage pushes a freshly saved message to every participant of its conversation
+func (h *ChatHandler) publishMessage(msg *models.Message) {
+	participants, err := h.chatRepo.GetParticipantIDs(msg.ConversationID)
+	if err != nil {
+		log.Printf("publishMessage: %v", err)
+		return
+	}
+
+	h.hub.SendToUsers(participants, realtime.Event{
+		Type: realtime.EventMessageNew,
+		Data: msg,
+	})
+
+	// A recipient with a live connection has just received the message
+	for _, id := range participants {
+		if id == msg.SenderID || !h.hub.IsOnline(id) {
+			continue
+		}
+		deliveredAt, err := h.chatRepo.MarkMessageDelivered(msg.ID)
+		if err != nil {
+			log.Printf("publishMessage: %v", err)
+			return
+		}
+		if deliveredAt != nil {
+			h.hub.SendToUser(msg.SenderID, realtime.Event{
+				Type: realtime.EventMessageDelivered,
+				Data: models.ReceiptUpdate{
+					ConversationID: msg.ConversationID,
+					UserID:         id,
+					UpToMessageID:  msg.ID,
+					At:             *deliveredAt,
+				},
+			})
+		}
+		return
+	}
+}
+
+// publishToConversation sends an event to every participant of a conversation
+func (h *ChatHandler) publishToConversation(conversationID int, evt realtime.Event) {
+	participants, err := h.chatRepo.GetParticipantIDs(conversationID)
+	if err != nil {
+		log.Printf("publishToConversation: %v", err)
+		return
+	}
+	h.hub.SendToUsers(participants, evt)
+}
+
+// publishReceipt tells the other participants of a conversation that their messages were delivered or read
+func (h *ChatHandler) publishReceipt(eventType string, receipt *models.ReceiptUpdate) {
+	if receipt == nil {
+		return
+	}
+
+	participants, err := h.chatRepo.GetParticipantIDs(receipt.ConversationID)
+	if err != nil {
+		log.Printf("publishReceipt: %v", err)
+		return
+	}
+
+	others := slices.DeleteFunc(participants, func(id int) bool { return id == receipt.UserID })
+	h.hub.SendToUsers(others, realtime.Event{
+		Type: eventType,
+		Data: receipt,
+	})
+}
@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/XORbit01/jobseeker-backend/models"
+	"github.com/XORbit01/jobseeker-backend/realtime"
+	"github.com/XORbit01/jobseeker-backend/repos"
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// sseKeepAlive is how often a comment is written so proxies do not close idle streams
+	sseKeepAlive = 25 * time.Second
+	// sseRetry is the reconnection delay suggested to clients, in milliseconds
+	sseRetry = 3000
+)
+
+// EventsHandler streams real-time events over Server-Sent Events
+type EventsHandler struct {
+	sessionRepo *repos.SessionRepository
+	hub         *realtime.Hub
+}
+
+// NewEventsHandler creates a new EventsHandler
+func NewEventsHandler(db *sql.DB, hub *realtime.Hub) *EventsHandler {
+	return &EventsHandler{
+		sessionRepo: repos.NewSessionRepository(db),
+		hub:         hub,
+	}
+}
+
+// RegisterEventRoutes registers the event stream
+func RegisterEventRoutes(router *gin.RouterGroup, db *sql.DB, hub *realtime.Hub) {
+	handler := NewEventsHandler(db, hub)
+	router.GET("/events", handler.StreamEvents)
+}
+
+// StreamEvents godoc
+//
+//	@Summary		Stream real-time events
+//	@Description	A `text/event-stream` alternative to the chat WebSocket for networks that block upgrades. Pass the JWT as `?token=` when using the browser EventSource API. Each event has an `id`, its type as the event name (`message.new`, `message.read`, `application.status`, ...) and the JSON payload as data. Reconnecting clients send `Last-Event-ID` (EventSource does this automatically) to receive the events they missed; when those are no longer available a `resync` event asks the client to reload its state.
+//	@Tags			Events
+//	@Security		BearerAuth
+//	@Produce		text/event-stream
+//	@Param			token			query	string	false	"JWT access token (EventSource cannot set headers)"
+//	@Param			Last-Event-ID	header	string	false	"ID of the last event received"
+//	@Param			last_event_id	query	string	false	"Same as the Last-Event-ID header"
+//	@Success		200
+//	@Failure		400	{object}	models.ErrorResponse
+//	@Failure		401	{object}	models.ErrorResponse
+//	@Router			/events [get]
+func (h *EventsHandler) StreamEvents(c *gin.Context) {
+	userID := c.GetInt("userID")
+	sessionID := c.GetInt("sessionID")
+
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("last_event_id")
+	}
+
+	var client *realtime.Client
+	var missed []realtime.Event
+	if lastEventID == "" {
+		client = h.hub.Register(userID, sessionID)
+	} else {
+		id, err := strconv.ParseUint(lastEventID, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Success: false,
+				Message: "Invalid Last-Event-ID",
+				Error:   &models.ErrorInfo{Code: "INVALID_INPUT", Details: err.Error()},
+			})
+			return
+		}
+		client, missed = h.hub.Resume(userID, sessionID, id)
+	}
+	defer h.hub.Unregister(client)
+
+	c.Header("Content-Type", sse.ContentType)
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // disable response buffering in nginx
+	c.Status(http.StatusOK)
+
+	fmt.Fprintf(c.Writer, "retry:%d\n\n", sseRetry)
+	for _, evt := range missed {
+		if err := writeSSE(c, evt); err != nil {
+			return
+		}
+	}
+	c.Writer.Flush()
+
+	ticker := time.NewTicker(sseKeepAlive)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case evt, ok := <-client.Send:
+			if !ok {
+				// Hub dropped the connection
+				return
+			}
+			if err := writeSSE(c, evt); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if sessionRevoked(h.sessionRepo, client) {
+				return
+			}
+			if _, err := fmt.Fprint(c.Writer, ": keepalive\n\n"); err != nil {
+				return
+			}
+		}
+		c.Writer.Flush()
+	}
+}
+
+// sessionRevoked reports whether the login session a live connection was opened with has been
+// logged out or revoked since. Connections are closed right away on logout, password and email
+// changes; this catches sessions ended any other way. Database errors keep the connection open.
+func sessionRevoked(sessions *repos.SessionRepository, client *realtime.Client) bool {
+	active, err := sessions.IsActive(client.SessionID, client.UserID)
+	if err != nil {
+		log.Printf("sessionRevoked: %v", err)
+		return false
+	}
+	return !active
+}
+
+// writeSSE writes one hub event to the stream with its payload encoded as JSON
+func writeSSE(c *gin.Context, evt realtime.Event) error {
+	data, err := json.Marshal(evt.Data)
+	if err != nil {
+		log.Printf("writeSSE: %v", err)
+		return nil
+	}
+	return sse.Encode(c.Writer, sse.Event{
+		Id:    strconv.FormatUint(evt.ID, 10),
+		Event: evt.Type,
+		Data:  data,
+	})
+}
@@ -2,13 +2,20 @@ package handlers
 
 import (
 	"fmt"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"path/filepath"
+	"strings"
 	"time"
+	"unicode"
 
 	"github.com/gin-gonic/gin"
 )
 
+// maxExtensionLength is the longest file extension, dot included, kept in stored file names
+const maxExtensionLength = 16
+
 // UploadFile godoc
 //
 //	@Summary		Upload a file (image or document)
@@ -23,6 +30,27 @@ import (
 //	@Failure		500		{object}	models.ErrorResponse
 //	@Router			/upload [post]
 func UploadFile(c *gin.Context) {
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "File upload failed", "error": err.Error()})
+		return
+	}
+
+	_, publicURL, err := storeUpload(c, file)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Failed to save file"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "File uploaded",
+		"data":    map[string]string{"url": publicURL},
+	})
+}
+
+// storeUpload saves a multipart file in the uploads directory and returns its path on disk and its public URL
+func storeUpload(c *gin.Context, file *multipart.FileHeader) (string, string, error) {
 	// Get configuration from context (set by middleware)
 	uploadsPath := c.GetString("uploadsPath")
 	staticURL := c.GetString("staticURL")
@@ -33,24 +61,44 @@ func UploadFile(c *gin.Context) {
 	if staticURL == "" {
 		staticURL = "/static"
 	}
-	file, err := c.FormFile("file")
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "File upload failed", "error": err.Error()})
-		return
-	}
 
-	filename := fmt.Sprintf("%s/%d_%s", uploadsPath, time.Now().Unix(), filepath.Base(file.Filename))
+	// The original name is not part of the stored one, so the URL stays short however long it is
+	filename := fmt.Sprintf("%s/%d%s", uploadsPath, time.Now().UnixNano(), uploadExtension(file.Filename))
 
 	if err := c.SaveUploadedFile(file, filename); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Failed to save file"})
-		return
+		return "", "", err
 	}
 
-	publicURL := staticURL + "/" + filepath.Base(filename)
+	return filename, staticURL + "/" + filepath.Base(filename), nil
+}
 
-	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"message": "File uploaded",
-		"data":    map[string]string{"url": publicURL},
-	})
+// uploadExtension returns the extension of an uploaded file's name, or "" when it is too long
+// or contains anything other than letters and digits
+func uploadExtension(name string) string {
+	ext := filepath.Ext(filepath.Base(name))
+	if len(ext) < 2 || len(ext) > maxExtensionLength {
+		return ""
+	}
+	for _, r := range ext[1:] {
+		if r > unicode.MaxASCII || !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			return ""
+		}
+	}
+	return strings.ToLower(ext)
+}
+
+// detectMimeType sniffs the content type of an uploaded file from its first bytes
+func detectMimeType(file *multipart.FileHeader) (string, error) {
+	f, err := file.Open()
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", err
+	}
+	return http.DetectContentType(head[:n]), nil
 }
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"strconv"
 
+	"github.com/XORbit01/jobseeker-backend/config"
 	"github.com/XORbit01/jobseeker-backend/middleware"
 	"github.com/XORbit01/jobseeker-backend/models"
 	"github.com/XORbit01/jobseeker-backend/repos"
@@ -26,14 +27,15 @@ func NewJobHandler(db *sql.DB) *JobHandler {
 }
 
 // RegisterJobRoutesPrivate registers job-related routes
-func RegisterJobRoutesPrivate(router *gin.RouterGroup, db *sql.DB) {
+func RegisterJobRoutesPrivate(router *gin.RouterGroup, db *sql.DB, cfg *config.Config) {
 	handler := NewJobHandler(db)
+	verified := middleware.VerifiedEmailMiddleware(repos.NewUserRepository(db), cfg.RequiresVerifiedEmail(config.VerifiedActionPostJobs))
 	// Employer-only routes
 	employerGroup := router.Group("/")
 	employerGroup.GET("/employer/listings", handler.GetEmployerJobs)
 	employerGroup.Use(middleware.RoleMiddleware("employer"))
 	{
-		employerGroup.POST("", handler.CreateJob)
+		employerGroup.POST("", verified, handler.CreateJob)
 		employerGroup.PUT("/:id", handler.UpdateJob)
 		employerGroup.DELETE("/:id", handler.DeleteJob)
 	}
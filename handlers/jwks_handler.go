@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/XORbit01/jobseeker-backend/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// JWKSHandler publishes the public keys access tokens are signed with
+type JWKSHandler struct {
+	keys *middleware.TokenKeys
+}
+
+// NewJWKSHandler creates a new JWKSHandler
+func NewJWKSHandler(keys *middleware.TokenKeys) *JWKSHandler {
+	return &JWKSHandler{keys: keys}
+}
+
+// RegisterJWKSRoutes registers the JWKS endpoint, usually under /.well-known
+func RegisterJWKSRoutes(router *gin.RouterGroup, keys *middleware.TokenKeys) {
+	handler := NewJWKSHandler(keys)
+	router.GET("/jwks.json", handler.GetJWKS)
+}
+
+// GetJWKS godoc
+//
+//	@Summary		Get the token signing keys
+//	@Description	Returns the public keys access tokens may be signed with as a JSON Web Key Set (RFC 7517), so other services can verify tokens. Tokens name their key in the `kid` header. The set is empty when tokens are signed with a shared secret. Unlike other endpoints, the response is not wrapped in the envelope, since JWKS clients expect a bare key set.
+//	@Tags			auth
+//	@Produce		json
+//	@Success		200	{object}	jwk.Set
+//	@Router			/.well-known/jwks.json [get]
+func (h *JWKSHandler) GetJWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.keys.JWKS())
+}
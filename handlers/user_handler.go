@@ -3,27 +3,35 @@ package handlers
 import (
 	"database/sql"
 	"net/http"
+	"strconv"
 
+	"github.com/XORbit01/jobseeker-backend/middleware"
 	"github.com/XORbit01/jobseeker-backend/models"
+	"github.com/XORbit01/jobseeker-backend/realtime"
 	"github.com/XORbit01/jobseeker-backend/repos"
 	"github.com/gin-gonic/gin"
 )
 
 type UserHandler struct {
-	userRepo *repos.UserRepository
+	userRepo     *repos.UserRepository
+	throttleRepo *repos.LoginThrottleRepository
+	hub          *realtime.Hub
 }
 
-func NewUserHandler(db *sql.DB) *UserHandler {
+func NewUserHandler(db *sql.DB, hub *realtime.Hub) *UserHandler {
 	return &UserHandler{
-		userRepo: repos.NewUserRepository(db),
+		userRepo:     repos.NewUserRepository(db),
+		throttleRepo: repos.NewLoginThrottleRepository(db),
+		hub:          hub,
 	}
 }
 
-func RegisterUserRoutes(router *gin.RouterGroup, db *sql.DB) {
-	handler := NewUserHandler(db)
+func RegisterUserRoutes(router *gin.RouterGroup, db *sql.DB, hub *realtime.Hub) {
+	handler := NewUserHandler(db, hub)
 
 	router.GET("/me", handler.GetCurrentUser)
 	router.DELETE("/me", handler.DeleteCurrentUser)
+	router.POST("/:user_id/unlock", middleware.RoleMiddleware("admin"), handler.UnlockUser)
 }
 
 //	@Summary		Get current user
@@ -93,9 +101,61 @@ func (h *UserHandler) DeleteCurrentUser(c *gin.Context) {
 		})
 		return
 	}
+	h.hub.DisconnectSessions(userID.(int))
 
 	c.JSON(http.StatusOK, models.SuccessResponse{
 		Success: true,
 		Message: "User deleted successfully",
 	})
 }
+
+// UnlockUser godoc
+//
+//	@Summary		Unlock a user's account
+//	@Description	Lifts a login lockout caused by too many failed attempts. Requires role: admin
+//	@Tags			Users
+//	@Security		BearerAuth
+//	@Produce		json
+//	@Param			user_id	path		int	true	"User ID"
+//	@Success		200		{object}	models.SuccessResponse
+//	@Failure		400		{object}	models.ErrorResponse
+//	@Failure		401		{object}	models.ErrorResponse
+//	@Failure		403		{object}	models.ErrorResponse
+//	@Failure		404		{object}	models.ErrorResponse
+//	@Failure		500		{object}	models.ErrorResponse
+//	@Router			/users/{user_id}/unlock [post]
+func (h *UserHandler) UnlockUser(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil || userID <= 0 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Message: "Invalid user ID",
+			Error:   &models.ErrorInfo{Code: "INVALID_INPUT"},
+		})
+		return
+	}
+
+	user, err := h.userRepo.GetByID(userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Success: false,
+			Message: "User not found",
+			Error:   &models.ErrorInfo{Code: "USER_NOT_FOUND"},
+		})
+		return
+	}
+
+	if err := h.throttleRepo.Reset(repos.EmailKey(user.Email)); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Message: "Failed to unlock account",
+			Error:   &models.ErrorInfo{Code: "DB_ERROR", Details: err.Error()},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Success: true,
+		Message: "Account unlocked",
+	})
+}
@@ -0,0 +1,104 @@
+package jwk
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"testing"
+)
+
+// TestThumbprintRFC7638 checks the example of RFC 7638 section 3.1
+func TestThumbprintRFC7638(t *testing.T) {
+	k := Key{
+		Kty: "RSA",
+		N: "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMs" +
+			"tn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5h" +
+			"ajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw",
+		E: "AQAB",
+	}
+	key, err := k.PublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Thumbprint(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs"; got != want {
+		t.Errorf("Thumbprint = %s, want %s", got, want)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edKey, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		key  crypto.PublicKey
+		alg  string
+	}{
+		{"RSA", &rsaKey.PublicKey, "RS256"},
+		{"Ed25519", edKey, "EdDSA"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k, err := FromPublicKey(tt.key, "key-1", tt.alg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, err := json.Marshal(Set{Keys: []Key{k}})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var set Set
+			if err := json.Unmarshal(data, &set); err != nil {
+				t.Fatal(err)
+			}
+			got, ok := set.PublicKeys()["key-1"]
+			if !ok {
+				t.Fatalf("key-1 missing from %s", data)
+			}
+			if !got.(interface{ Equal(crypto.PublicKey) bool }).Equal(tt.key) {
+				t.Errorf("decoded key differs from the original: %s", data)
+			}
+			if set.Keys[0].Alg != tt.alg || set.Keys[0].Use != "sig" {
+				t.Errorf("alg, use = %q, %q; want %q, sig", set.Keys[0].Alg, set.Keys[0].Use, tt.alg)
+			}
+
+			want, err := Thumbprint(tt.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, err := Thumbprint(got); err != nil || got != want {
+				t.Errorf("Thumbprint after round trip = %s, %v; want %s", got, err, want)
+			}
+		})
+	}
+}
+
+func TestPublicKeysSkipsOtherKeys(t *testing.T) {
+	edKey, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k, err := FromPublicKey(edKey, "enc", "EdDSA")
+	if err != nil {
+		t.Fatal(err)
+	}
+	k.Use = "enc"
+
+	set := Set{Keys: []Key{k, {Kty: "oct", Kid: "secret"}, {Kty: "OKP", Crv: "Ed448", Kid: "ed448"}}}
+	if keys := set.PublicKeys(); len(keys) != 0 {
+		t.Errorf("PublicKeys = %v, want none", keys)
+	}
+}
@@ -0,0 +1,86 @@
+package mailer
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/XORbit01/jobseeker-backend/config"
+)
+
+// Message is a plain-text email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers emails
+type Mailer interface {
+	Send(msg Message) error
+}
+
+// New returns an SMTP mailer when SMTP_HOST is configured. Otherwise emails are written to the log,
+// which is only meant for development.
+func New(cfg *config.Config) Mailer {
+	if cfg.SMTP.Host == "" {
+		log.Println("SMTP_HOST is not set: emails will be written to the log instead of being sent")
+		return LogMailer{}
+	}
+	return &SMTPMailer{
+		addr: net.JoinHostPort(cfg.SMTP.Host, cfg.SMTP.Port),
+		host: cfg.SMTP.Host,
+		user: cfg.SMTP.Username,
+		pass: cfg.SMTP.Password,
+		from: cfg.SMTP.From,
+	}
+}
+
+// SMTPMailer sends emails through an SMTP server, such as a provider's relay or a local
+// catcher like Mailpit. STARTTLS is used whenever the server offers it.
+type SMTPMailer struct {
+	addr string
+	host string
+	user string
+	pass string
+	from string
+}
+
+func (m *SMTPMailer) Send(msg Message) error {
+	if strings.ContainsAny(msg.To+msg.Subject, "\r\n") {
+		return errors.New("mailer: header values must not contain line breaks")
+	}
+
+	var auth smtp.Auth
+	if m.user != "" {
+		auth = smtp.PlainAuth("", m.user, m.pass, m.host)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", m.from)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+
+	if err := smtp.SendMail(m.addr, auth, m.from, []string{msg.To}, []byte(b.String())); err != nil {
+		return fmt.Errorf("mailer: %w", err)
+	}
+	return nil
+}
+
+// LogMailer writes emails to the log instead of sending them
+type LogMailer struct{}
+
+func (LogMailer) Send(msg Message) error {
+	log.Printf("email to %s: %s\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
@@ -1,15 +1,12 @@
 package middleware
 
 import (
-	"errors"
-	"fmt"
 	"net/http"
-	"os"
 	"slices"
 	"strings"
-	"time"
 
 	"github.com/XORbit01/jobseeker-backend/models"
+	"github.com/XORbit01/jobseeker-backend/repos"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 )
@@ -18,11 +15,13 @@ import (
 type TokenClaims struct {
 	UserID int    `json:"user_id"`
 	Role   string `json:"role"`
+	// SessionID is the login session the token was issued for
+	SessionID int `json:"sid,omitempty"`
 	jwt.RegisteredClaims
 }
 
-// AuthMiddleware protects routes by requiring a valid JWT token
-func AuthMiddleware() gin.HandlerFunc {
+// AuthMiddleware protects routes by requiring a valid JWT token whose session has not been revoked
+func AuthMiddleware(keys *TokenKeys, sessions *repos.SessionRepository) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var tokenStr string
 
@@ -49,7 +48,7 @@ func AuthMiddleware() gin.HandlerFunc {
 		}
 
 		// 4. Validate token
-		claims, err := validateToken(tokenStr)
+		claims, err := keys.validateToken(tokenStr)
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, models.ErrorResponse{
 				Success: false,
@@ -60,65 +59,33 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		// 5. Save user ID and role in context
-		c.Set("userID", claims.UserID)
-		c.Set("userRole", claims.Role)
-		c.Next()
-	}
-}
-
-// GenerateToken generates a JWT token for a user
-func GenerateToken(userID int, role string, tokenLifetime string) (string, error) {
-	duration, err := time.ParseDuration(tokenLifetime)
-	if err != nil {
-		duration = 24 * time.Hour
-	}
-
-	claims := TokenClaims{
-		UserID: userID,
-		Role:   role,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(duration)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-		},
-	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		return "", errors.New("JWT_SECRET environment variable is required")
-	}
-
-	return token.SignedString([]byte(jwtSecret))
-}
-
-// validateToken validates a JWT token and returns its claims
-func validateToken(tokenString string) (*TokenClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &TokenClaims{}, func(token *jwt.Token) (any, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		// 5. Reject tokens whose session was logged out or revoked
+		active, err := sessions.IsActive(claims.SessionID, claims.UserID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Success: false,
+				Message: "Failed to verify session",
+				Error:   &models.ErrorInfo{Code: "DB_ERROR", Details: err.Error()},
+			})
+			c.Abort()
+			return
 		}
-
-		jwtSecret := os.Getenv("JWT_SECRET")
-		if jwtSecret == "" {
-			return nil, errors.New("JWT_SECRET environment variable is required")
+		if !active {
+			c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+				Success: false,
+				Message: "Session has been logged out",
+				Error:   &models.ErrorInfo{Code: "TOKEN_REVOKED"},
+			})
+			c.Abort()
+			return
 		}
-		return []byte(jwtSecret), nil
-	})
-	if err != nil {
-		return nil, err
-	}
-
-	if !token.Valid {
-		return nil, errors.New("invalid token")
-	}
 
-	claims, ok := token.Claims.(*TokenClaims)
-	if !ok {
-		return nil, errors.New("could not parse claims")
+		// 6. Save user ID, role and session in context
+		c.Set("userID", claims.UserID)
+		c.Set("userRole", claims.Role)
+		c.Set("sessionID", claims.SessionID)
+		c.Next()
 	}
-
-	return claims, nil
 }
 
 func RoleMiddleware(allowedRoles ...string) gin.HandlerFunc {
@@ -148,3 +115,36 @@ func RoleMiddleware(allowedRoles ...string) gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// VerifiedEmailMiddleware rejects users who have not verified their email address yet.
+// When required is false every request is let through, so routes can follow the configured policy.
+func VerifiedEmailMiddleware(users *repos.UserRepository, required bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !required {
+			c.Next()
+			return
+		}
+
+		user, err := users.GetByID(c.GetInt("userID"))
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+				Success: false,
+				Message: "User not found",
+				Error:   &models.ErrorInfo{Code: "USER_NOT_FOUND"},
+			})
+			c.Abort()
+			return
+		}
+		if user.EmailVerifiedAt == nil {
+			c.JSON(http.StatusForbidden, models.ErrorResponse{
+				Success: false,
+				Message: "Please verify your email address first",
+				Error:   &models.ErrorInfo{Code: "EMAIL_NOT_VERIFIED"},
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,212 @@
+package middleware
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/XORbit01/jobseeker-backend/config"
+	"github.com/XORbit01/jobseeker-backend/jwk"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// TokenKeys signs and verifies access tokens.
+//
+// With an RSA or Ed25519 signing key, tokens are signed with RS256 or EdDSA and name their key in
+// the "kid" header. Tokens signed with any of the verification keys are accepted too, which allows
+// rotating keys without logging anyone out, and all public keys are published as a JWKS so other
+// services can verify tokens on their own. Without a signing key, tokens are signed with HS256 and
+// the JWT secret.
+type TokenKeys struct {
+	method     jwt.SigningMethod
+	signingKey any
+	keyID      string
+	// verificationKeys are the accepted public keys by key ID, including the signing key
+	verificationKeys map[string]verificationKey
+	jwks             jwk.Set
+}
+
+type verificationKey struct {
+	method jwt.SigningMethod
+	key    crypto.PublicKey
+}
+
+// LoadTokenKeys reads the signing and verification keys from the configured PEM files
+func LoadTokenKeys(cfg *config.Config) (*TokenKeys, error) {
+	if cfg.JWTSigningKeyFile == "" {
+		if len(cfg.JWTVerificationKeyFiles) > 0 {
+			return nil, errors.New("JWT_VERIFICATION_KEY_FILES requires JWT_SIGNING_KEY_FILE")
+		}
+		return &TokenKeys{
+			method:     jwt.SigningMethodHS256,
+			signingKey: []byte(cfg.JWTSecret),
+			jwks:       jwk.Set{Keys: []jwk.Key{}},
+		}, nil
+	}
+
+	key, err := readKeyFile(cfg.JWTSigningKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("JWT_SIGNING_KEY_FILE: %w", err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("JWT_SIGNING_KEY_FILE must contain a private key")
+	}
+
+	keys := &TokenKeys{
+		signingKey:       signer,
+		verificationKeys: make(map[string]verificationKey),
+		jwks:             jwk.Set{Keys: []jwk.Key{}},
+	}
+	keys.keyID, keys.method, err = keys.addVerificationKey(signer.Public())
+	if err != nil {
+		return nil, fmt.Errorf("JWT_SIGNING_KEY_FILE: %w", err)
+	}
+
+	for _, file := range cfg.JWTVerificationKeyFiles {
+		key, err := readKeyFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("JWT_VERIFICATION_KEY_FILES: %w", err)
+		}
+		if signer, ok := key.(crypto.Signer); ok {
+			key = signer.Public()
+		}
+		if _, _, err := keys.addVerificationKey(key); err != nil {
+			return nil, fmt.Errorf("JWT_VERIFICATION_KEY_FILES: %s: %w", file, err)
+		}
+	}
+	return keys, nil
+}
+
+// addVerificationKey accepts tokens signed with the key and publishes it. The key ID is the key's
+// RFC 7638 thumbprint, so it stays the same wherever the key is loaded.
+func (k *TokenKeys) addVerificationKey(key crypto.PublicKey) (string, jwt.SigningMethod, error) {
+	var method jwt.SigningMethod
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		if key.N.BitLen() < 2048 {
+			return "", nil, errors.New("RSA keys must have at least 2048 bits")
+		}
+		method = jwt.SigningMethodRS256
+	case ed25519.PublicKey:
+		method = jwt.SigningMethodEdDSA
+	default:
+		return "", nil, fmt.Errorf("unsupported key type %T; use an RSA or Ed25519 key", key)
+	}
+
+	kid, err := jwk.Thumbprint(key)
+	if err != nil {
+		return "", nil, err
+	}
+	if _, exists := k.verificationKeys[kid]; exists {
+		return kid, method, nil
+	}
+
+	published, err := jwk.FromPublicKey(key, kid, method.Alg())
+	if err != nil {
+		return "", nil, err
+	}
+	k.verificationKeys[kid] = verificationKey{method: method, key: key}
+	k.jwks.Keys = append(k.jwks.Keys, published)
+	return kid, method, nil
+}
+
+// readKeyFile reads a PEM-encoded private key (PKCS #8 or PKCS #1) or public key (PKIX or PKCS #1)
+func readKeyFile(path string) (any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM data found", path)
+	}
+
+	var key any
+	switch block.Type {
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		key, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("%s: unsupported PEM block %q", path, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return key, nil
+}
+
+// JWKS returns the public keys tokens may be signed with. It is empty when tokens are signed with HS256.
+func (k *TokenKeys) JWKS() jwk.Set {
+	return k.jwks
+}
+
+// GenerateToken generates a JWT access token for a user's session, valid until expiresAt
+func (k *TokenKeys) GenerateToken(userID int, role string, sessionID int, expiresAt time.Time) (string, error) {
+	claims := TokenClaims{
+		UserID:    userID,
+		Role:      role,
+		SessionID: sessionID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	token := jwt.NewWithClaims(k.method, claims)
+	if k.keyID != "" {
+		token.Header["kid"] = k.keyID
+	}
+	return token.SignedString(k.signingKey)
+}
+
+// validateToken validates a JWT token and returns its claims
+func (k *TokenKeys) validateToken(tokenString string) (*TokenClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &TokenClaims{}, k.keyFor)
+	if err != nil {
+		return nil, err
+	}
+
+	if !token.Valid {
+		return nil, errors.New("invalid token")
+	}
+
+	claims, ok := token.Claims.(*TokenClaims)
+	if !ok {
+		return nil, errors.New("could not parse claims")
+	}
+
+	return claims, nil
+}
+
+// keyFor returns the key a token must have been signed with: the key named in its "kid"
+// header, which also fixes the algorithm, or the JWT secret when signing with HS256
+func (k *TokenKeys) keyFor(token *jwt.Token) (any, error) {
+	if k.verificationKeys == nil {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return k.signingKey, nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	key, ok := k.verificationKeys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return key.key, nil
+}
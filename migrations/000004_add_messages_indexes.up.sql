@@ -0,0 +1,2 @@
+CREATE INDEX IF NOT EXISTS idx_messages_conversation_id ON messages (conversation_id, id);
+CREATE INDEX IF NOT EXISTS idx_messages_unread ON messages (conversation_id) WHERE is_read = FALSE;
@@ -0,0 +1,8 @@
+ALTER TABLE messages
+    ADD COLUMN IF NOT EXISTS delivered_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS read_at TIMESTAMPTZ;
+
+-- Messages already flagged as read were necessarily delivered
+UPDATE messages
+SET delivered_at = created_at, read_at = created_at
+WHERE is_read = TRUE;
@@ -0,0 +1,14 @@
+ALTER TABLE messages
+    ADD COLUMN IF NOT EXISTS edited_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+-- Previous versions of edited or deleted messages, kept for moderation
+CREATE TABLE IF NOT EXISTS message_revisions (
+    id SERIAL PRIMARY KEY,
+    message_id INT NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    content TEXT NOT NULL,
+    action VARCHAR(10) NOT NULL CHECK (action IN ('edit', 'delete')),
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_message_revisions_message_id ON message_revisions (message_id);
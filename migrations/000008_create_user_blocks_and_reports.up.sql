@@ -0,0 +1,27 @@
+-- Users a person no longer wants to hear from
+CREATE TABLE IF NOT EXISTS user_blocks (
+    blocker_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    blocked_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (blocker_id, blocked_id),
+    CHECK (blocker_id <> blocked_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_blocks_blocked_id ON user_blocks (blocked_id);
+
+-- Abuse reports raised from chat, reviewed by moderators
+CREATE TABLE IF NOT EXISTS message_reports (
+    id SERIAL PRIMARY KEY,
+    reporter_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reported_user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    conversation_id INT REFERENCES conversations(id) ON DELETE SET NULL,
+    message_id INT REFERENCES messages(id) ON DELETE SET NULL,
+    reason VARCHAR(20) NOT NULL CHECK (reason IN ('spam', 'scam', 'harassment', 'inappropriate', 'other')),
+    details TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'resolved', 'dismissed')),
+    reviewed_by INT REFERENCES users(id) ON DELETE SET NULL,
+    reviewed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_message_reports_status ON message_reports (status, created_at DESC);
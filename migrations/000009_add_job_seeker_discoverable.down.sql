@@ -0,0 +1,2 @@
+ALTER TABLE job_seeker_profiles
+    DROP COLUMN IF EXISTS discoverable;
@@ -0,0 +1,3 @@
+-- Candidates who opt in can be contacted by any employer, not only those they applied to
+ALTER TABLE job_seeker_profiles
+    ADD COLUMN IF NOT EXISTS discoverable BOOLEAN NOT NULL DEFAULT FALSE;
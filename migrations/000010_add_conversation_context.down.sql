@@ -0,0 +1,27 @@
+DROP INDEX IF EXISTS idx_conversations_application;
+DROP INDEX IF EXISTS idx_conversations_direct;
+
+-- Collapse context threads back into one conversation per pair of users
+UPDATE messages m
+SET conversation_id = keep.id
+FROM conversations c
+JOIN (
+    SELECT MIN(id) AS id, participant_one_id, participant_two_id
+    FROM conversations
+    GROUP BY participant_one_id, participant_two_id
+) keep ON keep.participant_one_id = c.participant_one_id AND keep.participant_two_id = c.participant_two_id
+WHERE m.conversation_id = c.id AND c.id <> keep.id;
+
+DELETE FROM conversations c
+USING conversations other
+WHERE c.participant_one_id = other.participant_one_id
+  AND c.participant_two_id = other.participant_two_id
+  AND c.id > other.id;
+
+ALTER TABLE conversations
+    DROP COLUMN IF EXISTS application_id,
+    DROP COLUMN IF EXISTS job_id;
+
+ALTER TABLE conversations
+    ADD CONSTRAINT conversations_participant_one_id_participant_two_id_key
+    UNIQUE (participant_one_id, participant_two_id);
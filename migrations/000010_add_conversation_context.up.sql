@@ -0,0 +1,18 @@
+-- Conversations may be tied to a job or to a single application, so that the same
+-- two people can keep separate threads for separate hiring pipelines
+ALTER TABLE conversations
+    ADD COLUMN IF NOT EXISTS job_id INT REFERENCES jobs(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS application_id INT REFERENCES applications(id) ON DELETE SET NULL;
+
+ALTER TABLE conversations
+    DROP CONSTRAINT IF EXISTS conversations_participant_one_id_participant_two_id_key;
+
+-- One general thread per pair of users
+CREATE UNIQUE INDEX IF NOT EXISTS idx_conversations_direct
+    ON conversations (participant_one_id, participant_two_id)
+    WHERE job_id IS NULL AND application_id IS NULL;
+
+-- One thread per application
+CREATE UNIQUE INDEX IF NOT EXISTS idx_conversations_application
+    ON conversations (application_id)
+    WHERE application_id IS NOT NULL;
@@ -0,0 +1,43 @@
+ALTER TABLE conversations
+    ADD COLUMN IF NOT EXISTS participant_one_id INT REFERENCES users(id) ON DELETE CASCADE,
+    ADD COLUMN IF NOT EXISTS participant_two_id INT REFERENCES users(id) ON DELETE CASCADE;
+
+-- Conversations that no longer have exactly two members cannot be represented
+DELETE FROM conversations c
+WHERE (SELECT COUNT(*) FROM conversation_participants cp WHERE cp.conversation_id = c.id) <> 2;
+
+UPDATE conversations c
+SET participant_one_id = p.one, participant_two_id = p.two
+FROM (
+    SELECT conversation_id, MIN(user_id) AS one, MAX(user_id) AS two
+    FROM conversation_participants
+    GROUP BY conversation_id
+) p
+WHERE p.conversation_id = c.id;
+
+ALTER TABLE conversations
+    ALTER COLUMN participant_one_id SET NOT NULL,
+    ALTER COLUMN participant_two_id SET NOT NULL;
+
+-- Keep the oldest general thread per pair
+DELETE FROM conversations c
+USING conversations other
+WHERE c.job_id IS NULL AND c.application_id IS NULL
+  AND other.job_id IS NULL AND other.application_id IS NULL
+  AND c.participant_one_id = other.participant_one_id
+  AND c.participant_two_id = other.participant_two_id
+  AND c.id > other.id;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_conversations_direct
+    ON conversations (participant_one_id, participant_two_id)
+    WHERE job_id IS NULL AND application_id IS NULL;
+
+DROP INDEX IF EXISTS idx_conversations_direct_key;
+
+ALTER TABLE conversations
+    DROP COLUMN IF EXISTS direct_key,
+    DROP COLUMN IF EXISTS created_by,
+    DROP COLUMN IF EXISTS title,
+    DROP COLUMN IF EXISTS is_group;
+
+DROP TABLE IF EXISTS conversation_participants;
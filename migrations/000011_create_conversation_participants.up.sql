@@ -0,0 +1,45 @@
+-- Conversations can have any number of members
+CREATE TABLE IF NOT EXISTS conversation_participants (
+    conversation_id INT NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    added_by INT REFERENCES users(id) ON DELETE SET NULL,
+    last_read_message_id INT,
+    joined_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (conversation_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_conversation_participants_user_id ON conversation_participants (user_id, conversation_id);
+
+ALTER TABLE conversations
+    ADD COLUMN IF NOT EXISTS is_group BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS title VARCHAR(255),
+    ADD COLUMN IF NOT EXISTS created_by INT REFERENCES users(id) ON DELETE SET NULL,
+    -- "<lower user id>:<higher user id>" for the general thread between two users, NULL otherwise
+    ADD COLUMN IF NOT EXISTS direct_key VARCHAR(32);
+
+-- Convert the two-party rows
+INSERT INTO conversation_participants (conversation_id, user_id, joined_at)
+SELECT id, participant_one_id, created_at FROM conversations
+UNION
+SELECT id, participant_two_id, created_at FROM conversations
+ON CONFLICT DO NOTHING;
+
+UPDATE conversation_participants cp
+SET last_read_message_id = (
+    SELECT MAX(m.id) FROM messages m
+    WHERE m.conversation_id = cp.conversation_id AND m.sender_id <> cp.user_id AND m.is_read = TRUE
+);
+
+UPDATE conversations
+SET direct_key = participant_one_id || ':' || participant_two_id
+WHERE job_id IS NULL AND application_id IS NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_conversations_direct_key
+    ON conversations (direct_key)
+    WHERE direct_key IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_conversations_direct;
+
+ALTER TABLE conversations
+    DROP COLUMN IF EXISTS participant_one_id,
+    DROP COLUMN IF EXISTS participant_two_id;
@@ -0,0 +1,2 @@
+CREATE INDEX IF NOT EXISTS idx_messages_content_search
+    ON messages USING GIN (to_tsvector('english', content));
@@ -0,0 +1,4 @@
+ALTER TABLE conversation_participants
+    DROP COLUMN IF EXISTS pinned_at,
+    DROP COLUMN IF EXISTS muted,
+    DROP COLUMN IF EXISTS archived_at;
@@ -0,0 +1,5 @@
+-- Per-user triage settings; each member sees their own
+ALTER TABLE conversation_participants
+    ADD COLUMN IF NOT EXISTS archived_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS muted BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS pinned_at TIMESTAMPTZ;
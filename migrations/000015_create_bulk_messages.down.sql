@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS bulk_message_recipients;
+DROP TABLE IF EXISTS bulk_messages;
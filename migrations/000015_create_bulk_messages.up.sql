@@ -0,0 +1,30 @@
+-- Messages sent to every applicant of a job, delivered in the background
+CREATE TABLE IF NOT EXISTS bulk_messages (
+    id SERIAL PRIMARY KEY,
+    employer_user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    job_id INT NOT NULL REFERENCES jobs(id) ON DELETE CASCADE,
+    application_status VARCHAR(20),
+    template_id INT REFERENCES message_templates(id) ON DELETE SET NULL,
+    body TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'running', 'completed')),
+    total_count INT NOT NULL DEFAULT 0,
+    sent_count INT NOT NULL DEFAULT 0,
+    failed_count INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    started_at TIMESTAMPTZ,
+    finished_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_bulk_messages_employer ON bulk_messages (employer_user_id, id);
+
+CREATE TABLE IF NOT EXISTS bulk_message_recipients (
+    bulk_message_id INT NOT NULL REFERENCES bulk_messages(id) ON DELETE CASCADE,
+    application_id INT NOT NULL REFERENCES applications(id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'sent', 'failed')),
+    conversation_id INT REFERENCES conversations(id) ON DELETE SET NULL,
+    message_id INT REFERENCES messages(id) ON DELETE SET NULL,
+    error TEXT,
+    processed_at TIMESTAMPTZ,
+    PRIMARY KEY (bulk_message_id, application_id)
+);
@@ -0,0 +1,2 @@
+ALTER TABLE users DROP COLUMN IF EXISTS email_verification_backfilled;
+ALTER TABLE users DROP COLUMN IF EXISTS email_verified_at;
@@ -0,0 +1,9 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMPTZ;
+-- Set for accounts that were treated as verified because they predate email verification,
+-- so that their ownership of the address is not taken as proven
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verification_backfilled BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Accounts created before verification existed keep posting jobs and messaging as before
+UPDATE users
+SET email_verified_at = created_at, email_verification_backfilled = TRUE
+WHERE email_verified_at IS NULL;
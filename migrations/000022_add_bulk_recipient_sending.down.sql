@@ -0,0 +1,4 @@
+UPDATE bulk_message_recipients SET status = 'failed' WHERE status = 'sending';
+ALTER TABLE bulk_message_recipients DROP CONSTRAINT IF EXISTS bulk_message_recipients_status_check;
+ALTER TABLE bulk_message_recipients ADD CONSTRAINT bulk_message_recipients_status_check
+    CHECK (status IN ('pending', 'sent', 'failed'));
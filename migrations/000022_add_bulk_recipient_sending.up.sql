@@ -0,0 +1,4 @@
+-- A recipient is claimed before its message is sent, so a restart never sends it twice
+ALTER TABLE bulk_message_recipients DROP CONSTRAINT IF EXISTS bulk_message_recipients_status_check;
+ALTER TABLE bulk_message_recipients ADD CONSTRAINT bulk_message_recipients_status_check
+    CHECK (status IN ('pending', 'sending', 'sent', 'failed'));
@@ -0,0 +1,2 @@
+-- An address the user asked to change to, which becomes their email once they confirm it
+ALTER TABLE users ADD COLUMN IF NOT EXISTS pending_email VARCHAR(255);
@@ -19,6 +19,9 @@ type Application struct {
 	LastName    string `json:"last_name,omitempty" example:"Khalil"`
 	LogoURL     string `json:"logo_url"`
 	ResumeURL   string `json:"resume_url" example:"/uploads/resumes/ali_resume.pdf"`
+
+	// ConversationID is the chat thread opened for this application, if any
+	ConversationID *int `json:"conversation_id" example:"12"`
 }
 
 // ApplicationInput represents the data needed to create an application
@@ -27,6 +30,15 @@ type ApplicationInput struct {
 	CoverLetter string `json:"cover_letter" example:"I'm highly motivated to join your team. Here's why I think I'd be a great fit..."`
 }
 
+// ApplicationStatusUpdate is pushed to the candidate when an employer changes an application's status
+type ApplicationStatusUpdate struct {
+	ApplicationID int       `json:"application_id" example:"42"`
+	JobID         int       `json:"job_id" example:"101"`
+	JobTitle      string    `json:"job_title" example:"Backend Engineer"`
+	Status        string    `json:"status" example:"interview"`
+	UpdatedAt     time.Time `json:"updated_at" example:"2025-04-14T10:18:32Z"`
+}
+
 // ApplicationStatusInput represents the data needed to update an application status
 type ApplicationStatusInput struct {
 	Status string `json:"status" binding:"required,oneof=pending reviewed interview rejected accepted" example:"interview"`
@@ -0,0 +1,66 @@
+package models
+
+import "time"
+
+// Bulk message states
+const (
+	BulkMessagePending   = "pending"
+	BulkMessageRunning   = "running"
+	BulkMessageCompleted = "completed"
+)
+
+// Recipient states
+const (
+	BulkRecipientPending = "pending"
+	BulkRecipientSending = "sending" // claimed, the message is being sent
+	BulkRecipientSent    = "sent"
+	BulkRecipientFailed  = "failed"
+)
+
+// Reasons a recipient failed
+const (
+	BulkErrorNotParticipant   = "NOT_A_PARTICIPANT"
+	BulkErrorUserBlocked      = "USER_BLOCKED"
+	BulkErrorTemplateVariable = "TEMPLATE_VARIABLE_MISSING"
+	BulkErrorDelivery         = "DELIVERY_FAILED"
+	// BulkErrorInterrupted means a restart happened while sending, so the message may or may not have been posted
+	BulkErrorInterrupted = "DELIVERY_INTERRUPTED"
+)
+
+// BulkMessageInput sends one message to every applicant of a job, optionally only those
+// whose application has the given status. Either Content or TemplateID is required.
+type BulkMessageInput struct {
+	JobID      int    `json:"job_id" binding:"required,min=1" example:"101"`
+	Status     string `json:"status" binding:"omitempty,oneof=pending reviewed interview rejected accepted" example:"pending"`
+	Content    string `json:"content" binding:"max=5000" example:"Thank you for your interest. The position has now been filled."`
+	TemplateID int    `json:"template_id" example:"3"`
+}
+
+// BulkMessage tracks the delivery of a message to a job's applicants
+type BulkMessage struct {
+	ID                int                    `json:"id" example:"8"`
+	EmployerUserID    int                    `json:"employer_user_id" example:"2"`
+	JobID             int                    `json:"job_id" example:"101"`
+	ApplicationStatus string                 `json:"application_status,omitempty" example:"pending"` // empty when every applicant was targeted
+	TemplateID        *int                   `json:"template_id,omitempty" example:"3"`
+	Body              string                 `json:"body" example:"Hi {{first_name}}, the {{job_title}} position has now been filled."`
+	Status            string                 `json:"status" example:"running"` // pending, running or completed
+	TotalCount        int                    `json:"total_count" example:"40"`
+	SentCount         int                    `json:"sent_count" example:"25"`
+	FailedCount       int                    `json:"failed_count" example:"1"`
+	CreatedAt         time.Time              `json:"created_at" example:"2025-04-14T10:18:32Z"`
+	StartedAt         *time.Time             `json:"started_at"`
+	FinishedAt        *time.Time             `json:"finished_at"`
+	Recipients        []BulkMessageRecipient `json:"recipients,omitempty"`
+}
+
+// BulkMessageRecipient is the delivery result for one applicant
+type BulkMessageRecipient struct {
+	ApplicationID  int        `json:"application_id" example:"42"`
+	UserID         int        `json:"user_id" example:"6"`
+	Status         string     `json:"status" example:"sent"` // pending, sending, sent or failed
+	ConversationID *int       `json:"conversation_id" example:"12"`
+	MessageID      *int       `json:"message_id" example:"341"`
+	Error          string     `json:"error,omitempty" example:"USER_BLOCKED"` // one of the BulkError codes
+	ProcessedAt    *time.Time `json:"processed_at"`
+}
@@ -3,28 +3,221 @@ package models
 import "time"
 
 type Conversation struct {
-	ID               int       `json:"id"`
-	ParticipantOneID int       `json:"participant_one_id"`
-	ParticipantTwoID int       `json:"participant_two_id"`
-	CreatedAt        time.Time `json:"created_at"`
+	ID             int       `json:"id"`
+	IsGroup        bool      `json:"is_group"`
+	Title          string    `json:"title,omitempty" example:"Backend Engineer - Ali Khalil"`
+	ParticipantIDs []int     `json:"participant_ids" example:"2,6,9"`
+	CreatedBy      *int      `json:"created_by,omitempty" example:"6"`
+	JobID          *int      `json:"job_id,omitempty" example:"101"`        // set when the conversation is about a job
+	ApplicationID  *int      `json:"application_id,omitempty" example:"42"` // set when the conversation belongs to an application
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// GroupInput creates a group conversation. The creator is always a member.
+type GroupInput struct {
+	Title          string `json:"title" binding:"required,max=255" example:"Backend Engineer - Ali Khalil"`
+	ParticipantIDs []int  `json:"participant_ids" binding:"required,min=1,max=49" example:"2,9"`
+}
+
+// ParticipantsInput adds members to a conversation
+type ParticipantsInput struct {
+	UserIDs []int `json:"user_ids" binding:"required,min=1,max=49" example:"9"`
 }
 
 type Message struct {
-	ID             int       `json:"id"`
-	ConversationID int       `json:"conversation_id,omitempty"` // populated after save
-	SenderID       int       `json:"sender_id"`
-	ReceiverID     int       `json:"receiver_id,omitempty"` // used only when sending
-	Content        string    `json:"content"`
-	Timestamp      time.Time `json:"timestamp,omitempty"`
-	Read           bool      `json:"read,omitempty"`
+	ID             int                 `json:"id"`
+	ConversationID int                 `json:"conversation_id,omitempty"` // populated after save
+	SenderID       int                 `json:"sender_id"`
+	ReceiverID     int                 `json:"receiver_id,omitempty"` // used only when sending
+	Content        string              `json:"content"`
+	Timestamp      time.Time           `json:"timestamp,omitempty"`
+	Read           bool                `json:"read,omitempty"`
+	DeliveredAt    *time.Time          `json:"delivered_at"`
+	ReadAt         *time.Time          `json:"read_at"`
+	EditedAt       *time.Time          `json:"edited_at"`
+	DeletedAt      *time.Time          `json:"deleted_at"` // set on tombstones; content and attachments are hidden
+	Attachments    []MessageAttachment `json:"attachments,omitempty"`
+}
+
+// MessageEditInput replaces the text of a message
+type MessageEditInput struct {
+	Content string `json:"content" binding:"required" example:"Would Thursday at 3 PM work instead?"`
+}
+
+// MessageRevision is an earlier version of a message kept for moderation
+type MessageRevision struct {
+	ID        int       `json:"id" example:"4"`
+	MessageID int       `json:"message_id" example:"341"`
+	Content   string    `json:"content" example:"Would Thursday at 2 PM work?"`
+	Action    string    `json:"action" example:"edit"` // edit or delete
+	CreatedAt time.Time `json:"created_at" example:"2025-04-14T10:18:32Z"`
+}
+
+// MessageAttachment is a file sent along with a message
+type MessageAttachment struct {
+	ID        int       `json:"id" example:"7"`
+	MessageID int       `json:"message_id" example:"341"`
+	FileName  string    `json:"file_name" example:"ali_khalil_cv.pdf"`
+	MimeType  string    `json:"mime_type" example:"application/pdf"`
+	SizeBytes int64     `json:"size_bytes" example:"184320"`
+	URL       string    `json:"url" example:"/static/1747207683123456789_ali_khalil_cv.pdf"`
+	CreatedAt time.Time `json:"created_at" example:"2025-04-14T10:18:32Z"`
+	Path      string    `json:"-"` // location on disk, used to clean up failed sends
 }
 
+// MessageInput is the JSON body for sending a message. The same endpoint also accepts
+// multipart/form-data with `content`, `template_id` and `job_id` fields and up to five `attachments` files.
+// Employers may send one of their templates instead of content; JobID then fills {{job_title}}
+// when the conversation is not already about a job.
 type MessageInput struct {
 	ReceiverID int    `json:"receiver_id"`
 	Content    string `json:"content"`
+	TemplateID int    `json:"template_id,omitempty" example:"3"`
+	JobID      int    `json:"job_id,omitempty" example:"101"`
+}
+
+// ReadReceiptInput marks messages as read up to a given message.
+// Omit the body (or send 0) to mark the whole conversation as read.
+type ReadReceiptInput struct {
+	UpToMessageID int `json:"up_to_message_id" example:"341"`
+}
+
+// ReceiptUpdate tells senders that a participant received or read their messages
+type ReceiptUpdate struct {
+	ConversationID int       `json:"conversation_id" example:"12"`
+	UserID         int       `json:"user_id" example:"2"` // participant who received or read the messages
+	UpToMessageID  int       `json:"up_to_message_id" example:"341"`
+	At             time.Time `json:"at" example:"2025-04-14T10:18:32Z"`
+}
+
+// CursorParams selects a page of results relative to an item ID.
+// Before walks backwards (older items), After walks forwards (newer items).
+type CursorParams struct {
+	Before int `form:"before" example:"120"`
+	After  int `form:"after" example:"0"`
+	Limit  int `form:"limit,default=50" example:"50"`
+}
+
+// MessagePage is a page of messages in ascending order
+type MessagePage struct {
+	Messages   []Message `json:"messages"`
+	NextCursor *int      `json:"next_cursor" example:"71"` // pass as before/after to keep paging in the same direction, null when exhausted
+}
+
+// ConversationPage is a page of conversations, newest first
+type ConversationPage struct {
+	Conversations []Conversation `json:"conversations"`
+	NextCursor    *int           `json:"next_cursor" example:"9"`
 }
 
 type ConversationWithStats struct {
 	Conversation
-	MessageCount int `json:"message_count"`
+	MessageCount int       `json:"message_count"`
+	Counterpart  *Presence `json:"counterpart"` // null for group conversations
+}
+
+// InboxEntry is one conversation as shown in the user's inbox
+type InboxEntry struct {
+	ConversationID int               `json:"conversation_id" example:"12"`
+	IsGroup        bool              `json:"is_group"`
+	Title          string            `json:"title,omitempty" example:"Backend Engineer - Ali Khalil"`
+	JobID          *int              `json:"job_id,omitempty" example:"101"`
+	ApplicationID  *int              `json:"application_id,omitempty" example:"42"`
+	JobTitle       string            `json:"job_title,omitempty" example:"Backend Engineer"`
+	Counterpart    *InboxCounterpart `json:"counterpart"`  // null for group conversations
+	LastMessage    *MessagePreview   `json:"last_message"` // null when the conversation has no messages yet
+	UnreadCount    int               `json:"unread_count" example:"3"`
+	LastActivityAt time.Time         `json:"last_activity_at" example:"2025-04-14T10:18:32Z"`
+	Archived       bool              `json:"archived"`
+	Muted          bool              `json:"muted"`
+	Pinned         bool              `json:"pinned"`
+}
+
+// Inbox filters
+const (
+	InboxFilterActive   = "active" // everything that is not archived
+	InboxFilterArchived = "archived"
+	InboxFilterPinned   = "pinned"
+	InboxFilterMuted    = "muted"
+	InboxFilterUnread   = "unread"
+	InboxFilterAll      = "all"
+)
+
+// InboxParams selects which conversations the inbox shows
+type InboxParams struct {
+	Filter string `form:"filter,default=active" binding:"oneof=active archived pinned muted unread all" example:"active"`
+}
+
+// ConversationSettingsInput changes how a conversation appears for the current user only.
+// Omitted fields keep their current value.
+type ConversationSettingsInput struct {
+	Archived *bool `json:"archived" example:"true"`
+	Muted    *bool `json:"muted" example:"false"`
+	Pinned   *bool `json:"pinned" example:"false"`
+}
+
+// ConversationSettings is the current user's view of a conversation.
+// Archived conversations return to the inbox when a new message arrives, unless they are also muted.
+// Muted conversations still receive messages; clients should not alert for them.
+type ConversationSettings struct {
+	ConversationID int        `json:"conversation_id" example:"12"`
+	Archived       bool       `json:"archived" example:"true"`
+	Muted          bool       `json:"muted" example:"false"`
+	Pinned         bool       `json:"pinned" example:"false"`
+	ArchivedAt     *time.Time `json:"archived_at,omitempty" example:"2025-04-14T10:18:32Z"`
+	PinnedAt       *time.Time `json:"pinned_at,omitempty"`
+}
+
+// InboxCounterpart describes the other participant of a conversation
+type InboxCounterpart struct {
+	Presence
+	Role        string `json:"role" example:"employer"`
+	DisplayName string `json:"display_name" example:"Tech Innovations Inc."`
+	AvatarURL   string `json:"avatar_url" example:"/static/1747207683_logo.png"`
+}
+
+// MessagePreview is a shortened view of the latest message in a conversation
+type MessagePreview struct {
+	ID       int       `json:"id" example:"341"`
+	SenderID int       `json:"sender_id" example:"6"`
+	Snippet  string    `json:"snippet" example:"Would you be available for an interview this week?"`
+	SentAt   time.Time `json:"sent_at" example:"2025-04-14T10:18:32Z"`
+}
+
+// MessageSearchParams filters a full-text search over the caller's messages
+type MessageSearchParams struct {
+	Query          string `form:"q" binding:"required,max=200" example:"interview thursday"`
+	ConversationID int    `form:"conversation_id" example:"12"` // limit the search to one conversation
+	Page           int    `form:"page,default=1" example:"1"`
+	Limit          int    `form:"limit,default=20" example:"20"`
+}
+
+// MessageSearchResult is a message matching a search. The snippet is HTML-escaped and the matched words are wrapped in <mark> tags.
+type MessageSearchResult struct {
+	MessageID      int       `json:"message_id" example:"341"`
+	ConversationID int       `json:"conversation_id" example:"12"`
+	SenderID       int       `json:"sender_id" example:"6"`
+	Snippet        string    `json:"snippet" example:"Would Thursday at 3 PM work for the <mark>interview</mark>?"`
+	SentAt         time.Time `json:"sent_at" example:"2025-04-14T10:18:32Z"`
+}
+
+// Presence tells whether a user currently has a live connection
+type Presence struct {
+	UserID     int        `json:"user_id"`
+	Online     bool       `json:"online"`
+	LastSeenAt *time.Time `json:"last_seen_at,omitempty"`
+}
+
+// TypingIndicator is relayed to the other participants of a conversation
+type TypingIndicator struct {
+	ConversationID int  `json:"conversation_id"`
+	UserID         int  `json:"user_id"`
+	IsTyping       bool `json:"is_typing"`
+}
+
+// ChatClientEvent is a frame sent by the client over the WebSocket
+type ChatClientEvent struct {
+	Type           string `json:"type" example:"typing"`
+	ConversationID int    `json:"conversation_id" example:"12"`
+	IsTyping       bool   `json:"is_typing" example:"true"`
 }
@@ -16,6 +16,7 @@ type JobSeekerProfile struct {
 	LogoUrl         string    `json:"logo_url" example:"/uploads/resumes/ali_pfp.jpeg"`
 	Skills          []string  `json:"skills" example:["Go","PostgreSQL","Docker"]`
 	ExperienceLevel string    `json:"experience_level" validate:"omitempty,oneof='Entry-level' 'Mid-level' 'Senior' 'Lead'" example:"Mid-level"`
+	Discoverable    bool      `json:"discoverable" example:"true"` // employers may message the candidate without an application
 	CreatedAt       time.Time `json:"created_at" example:"2025-04-14T10:18:32Z"`
 	UpdatedAt       time.Time `json:"updated_at" example:"2025-04-14T10:18:32Z"`
 }
@@ -32,4 +33,5 @@ type JobSeekerProfileInput struct {
 	LogoUrl         string   `json:"logo_url" example:"/uploads/resumes/ali_pfp.jpeg"`
 	Skills          []string `json:"skills" example:["Go","PostgreSQL","Docker"]`
 	ExperienceLevel string   `json:"experience_level" validate:"omitempty,oneof='Entry-level' 'Mid-level' 'Senior' 'Lead'" example:"Mid-level"`
+	Discoverable    bool     `json:"discoverable" example:"true"`
 }
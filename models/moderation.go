@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// UserBlock is an entry in the current user's block list
+type UserBlock struct {
+	UserID    int       `json:"user_id" example:"14"`
+	CreatedAt time.Time `json:"created_at" example:"2025-04-14T10:18:32Z"`
+}
+
+// ReportInput is sent by a user to flag abusive behaviour in chat
+type ReportInput struct {
+	ReportedUserID int    `json:"reported_user_id" binding:"required" example:"14"`
+	ConversationID *int   `json:"conversation_id" example:"12"`
+	MessageID      *int   `json:"message_id" example:"341"`
+	Reason         string `json:"reason" binding:"required,oneof=spam scam harassment inappropriate other" example:"scam"`
+	Details        string `json:"details" binding:"max=2000" example:"Asked me to pay a fee to get an interview"`
+}
+
+// MessageReport is a report as seen by moderators
+type MessageReport struct {
+	ID             int        `json:"id" example:"3"`
+	ReporterID     int        `json:"reporter_id" example:"6"`
+	ReportedUserID int        `json:"reported_user_id" example:"14"`
+	ConversationID *int       `json:"conversation_id,omitempty" example:"12"`
+	MessageID      *int       `json:"message_id,omitempty" example:"341"`
+	Reason         string     `json:"reason" example:"scam"`
+	Details        string     `json:"details,omitempty"`
+	Status         string     `json:"status" example:"open"`
+	ReviewedBy     *int       `json:"reviewed_by,omitempty" example:"1"`
+	ReviewedAt     *time.Time `json:"reviewed_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at" example:"2025-04-14T10:18:32Z"`
+}
+
+// ReportStatusInput is used by moderators to close a report
+type ReportStatusInput struct {
+	Status string `json:"status" binding:"required,oneof=open resolved dismissed" example:"resolved"`
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// OIDCAuthorization starts a login with an OpenID Connect provider. The frontend sends the user to
+// the authorization URL and, when the provider sends them back, checks that the state matches.
+type OIDCAuthorization struct {
+	AuthorizationURL string    `json:"authorization_url" example:"https://accounts.google.com/o/oauth2/v2/auth?client_id=...&code_challenge=...&state=..."`
+	State            string    `json:"state" example:"kX1f0tq3R2m9Yb7cVw8ZpA4sLd6HgJeN5uQo1iTzKyE"`
+	ExpiresAt        time.Time `json:"expires_at" example:"2025-04-14T10:28:32Z"`
+}
+
+// OIDCCallbackInput carries the query parameters the provider sent the user back with
+type OIDCCallbackInput struct {
+	Code  string `json:"code" binding:"required" example:"4/0AeaYSHB..."`
+	State string `json:"state" binding:"required" example:"kX1f0tq3R2m9Yb7cVw8ZpA4sLd6HgJeN5uQo1iTzKyE"`
+}
@@ -13,8 +13,9 @@ type ErrorResponse struct {
 }
 
 type ErrorInfo struct {
-	Code    string `json:"code"`              // e.g., "USER_NOT_FOUND"
-	Details string `json:"details,omitempty"` // optional: stack trace, etc.
+	Code       string `json:"code"`                  // e.g., "USER_NOT_FOUND"
+	Details    string `json:"details,omitempty"`     // optional: stack trace, etc.
+	RetryAfter int    `json:"retry_after,omitempty"` // seconds to wait before retrying, e.g. with ACCOUNT_LOCKED
 }
 
 // PaginatedResponse represents a paginated response from the API
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// Template variables that can be used in a message template as {{name}}
+const (
+	TemplateVarFirstName   = "first_name"   // the candidate's first name
+	TemplateVarJobTitle    = "job_title"    // the job the conversation is about, or the job_id sent with the message
+	TemplateVarCompanyName = "company_name" // the sender's company
+)
+
+// MessageTemplate is a reusable message owned by an employer
+type MessageTemplate struct {
+	ID        int       `json:"id" example:"3"`
+	Name      string    `json:"name" example:"Thanks for applying"`
+	Body      string    `json:"body" example:"Hi {{first_name}}, thanks for applying to {{job_title}} at {{company_name}}. Are you free for a call this week?"`
+	CreatedAt time.Time `json:"created_at" example:"2025-04-14T10:18:32Z"`
+	UpdatedAt time.Time `json:"updated_at" example:"2025-04-14T10:18:32Z"`
+}
+
+// MessageTemplateInput creates or replaces a message template
+type MessageTemplateInput struct {
+	Name string `json:"name" binding:"required,max=100" example:"Thanks for applying"`
+	Body string `json:"body" binding:"required,max=5000" example:"Hi {{first_name}}, thanks for applying to {{job_title}} at {{company_name}}."`
+}
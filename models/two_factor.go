@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// TwoFactorSetup is returned when a user starts enrolling an authenticator app
+type TwoFactorSetup struct {
+	Secret          string `json:"secret" example:"JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"`
+	ProvisioningURI string `json:"provisioning_uri" example:"otpauth://totp/Career%20Pulse:jane@jobportal.com?algorithm=SHA1&digits=6&issuer=Career%20Pulse&period=30&secret=JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"`
+}
+
+// TwoFactorCodeInput carries a code from the authenticator app
+type TwoFactorCodeInput struct {
+	Code string `json:"code" binding:"required" example:"492039"`
+}
+
+// DisableTwoFactorInput turns two-factor authentication off. The code may be a recovery code.
+type DisableTwoFactorInput struct {
+	Password string `json:"password" binding:"required" example:"Str0ngPass!"`
+	Code     string `json:"code" binding:"required" example:"492039"`
+}
+
+// TwoFactorRecoveryCodes are shown once, when two-factor authentication is enabled
+type TwoFactorRecoveryCodes struct {
+	RecoveryCodes []string `json:"recovery_codes" example:"7KQ2M-XV9TP,H3WZD-PL6RN"`
+}
+
+// TwoFactorChallenge is returned by login instead of tokens when the account uses two-factor authentication
+type TwoFactorChallenge struct {
+	TwoFactorRequired bool      `json:"two_factor_required" example:"true"`
+	ChallengeToken    string    `json:"challenge_token" example:"kX1f0tq3R2m9Yb7cVw8ZpA4sLd6HgJeN5uQo1iTzKyE"`
+	ExpiresAt         time.Time `json:"expires_at" example:"2025-04-14T10:23:32Z"`
+}
+
+// TwoFactorLoginInput completes a login with a code from the authenticator app or a recovery code
+type TwoFactorLoginInput struct {
+	ChallengeToken string `json:"challenge_token" binding:"required" example:"kX1f0tq3R2m9Yb7cVw8ZpA4sLd6HgJeN5uQo1iTzKyE"`
+	Code           string `json:"code" binding:"required" example:"492039"`
+}
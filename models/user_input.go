@@ -3,12 +3,14 @@ package models
 import "time"
 
 type User struct {
-	ID           int       `json:"id" example:"1"`
-	Email        string    `json:"email" example:"user@example.com"`
-	PasswordHash string    `json:"-"` // hidden from JSON
-	Role         string    `json:"role" example:"job_seeker"`
-	CreatedAt    time.Time `json:"created_at" example:"2025-04-14T10:18:32Z"`
-	UpdatedAt    time.Time `json:"updated_at" example:"2025-04-14T10:18:32Z"`
+	ID              int        `json:"id" example:"1"`
+	Email           string     `json:"email" example:"user@example.com"`
+	PasswordHash    string     `json:"-"` // hidden from JSON
+	Role            string     `json:"role" example:"job_seeker"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at" example:"2025-04-14T10:20:05Z"`
+	PendingEmail    string     `json:"pending_email,omitempty" example:"jane.doe@jobportal.com"` // awaiting confirmation
+	CreatedAt       time.Time  `json:"created_at" example:"2025-04-14T10:18:32Z"`
+	UpdatedAt       time.Time  `json:"updated_at" example:"2025-04-14T10:18:32Z"`
 }
 
 type UserInput struct {
@@ -23,6 +25,39 @@ type LoginInput struct {
 }
 
 type TokenResponse struct {
-	Token string `json:"token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."` // shortened JWT
-	Role  string `json:"role" example:"job_seeker"`
+	Token        string    `json:"token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."` // shortened JWT
+	ExpiresAt    time.Time `json:"expires_at" example:"2025-04-14T10:33:32Z"`               // when the access token expires
+	RefreshToken string    `json:"refresh_token" example:"kX1f0tq3R2m9Yb7cVw8ZpA4sLd6HgJeN5uQo1iTzKyE"`
+	Role         string    `json:"role" example:"job_seeker"`
+}
+
+type ForgotPasswordInput struct {
+	Email string `json:"email" binding:"required,email" example:"jane@jobportal.com"`
+}
+
+type ResetPasswordInput struct {
+	Token    string `json:"token" binding:"required" example:"kX1f0tq3R2m9Yb7cVw8ZpA4sLd6HgJeN5uQo1iTzKyE"`
+	Password string `json:"password" binding:"required,min=8" example:"N3wStr0ngPass!"`
+}
+
+type VerifyEmailInput struct {
+	Token string `json:"token" binding:"required" example:"42.1744712312.Xq3v9b0m7QkR2sLd6HgJeN5uQo1iTzKyEkX1f0tq3R2"`
+}
+
+type UnlockAccountInput struct {
+	Token string `json:"token" binding:"required" example:"42.1744712312.Xq3v9b0m7QkR2sLd6HgJeN5uQo1iTzKyEkX1f0tq3R2"`
+}
+
+type RefreshTokenInput struct {
+	RefreshToken string `json:"refresh_token" binding:"required" example:"kX1f0tq3R2m9Yb7cVw8ZpA4sLd6HgJeN5uQo1iTzKyE"`
+}
+
+type ChangePasswordInput struct {
+	CurrentPassword string `json:"current_password" binding:"required" example:"Str0ngPass!"`
+	NewPassword     string `json:"new_password" binding:"required,min=8" example:"N3wStr0ngPass!"`
+}
+
+type ChangeEmailInput struct {
+	NewEmail string `json:"new_email" binding:"required,email" example:"jane.doe@jobportal.com"`
+	Password string `json:"password" binding:"required" example:"Str0ngPass!"`
 }
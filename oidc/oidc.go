@@ -0,0 +1,323 @@
+// Package oidc logs users in with OpenID Connect providers: the authorization code flow with
+// PKCE (RFC 7636), provider discovery and ID token validation
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/XORbit01/jobseeker-backend/config"
+	"github.com/XORbit01/jobseeker-backend/jwk"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// discoveryTTL is how long a provider's discovery document and signing keys are cached
+	discoveryTTL = time.Hour
+	// minKeyRefresh limits how often the signing keys are refetched for tokens signed with an unknown key
+	minKeyRefresh = time.Minute
+	// clockSkew is how far the provider's clock may be off when checking token times
+	clockSkew = time.Minute
+	// maxResponseSize caps how much of a provider response is read
+	maxResponseSize = 1 << 20
+)
+
+// scopes are requested on every login: the ID token, the email address and the user's name
+const scopes = "openid email profile"
+
+// signingMethods are the accepted ID token algorithms. HMAC is left out on purpose, since it would
+// make the client secret a signing key.
+var signingMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+
+// Identity is what a provider asserts about the user who logged in
+type Identity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// Provider is an OpenID Connect provider users log in with. It is safe for concurrent use.
+type Provider struct {
+	name         string
+	issuer       string
+	clientID     string
+	clientSecret string
+	redirectURL  string
+	client       *http.Client
+
+	mu            sync.Mutex
+	metadata      *metadata
+	fetchedAt     time.Time
+	keys          map[string]crypto.PublicKey
+	keysFetchedAt time.Time
+}
+
+// metadata is the part of the discovery document the login flow uses
+type metadata struct {
+	Issuer                string   `json:"issuer"`
+	AuthorizationEndpoint string   `json:"authorization_endpoint"`
+	TokenEndpoint         string   `json:"token_endpoint"`
+	JWKSURI               string   `json:"jwks_uri"`
+	TokenAuthMethods      []string `json:"token_endpoint_auth_methods_supported"`
+}
+
+// NewProvider returns a provider for the configuration. Its discovery document is fetched on first use.
+func NewProvider(cfg config.OIDCProviderConfig) *Provider {
+	return &Provider{
+		name:         cfg.Name,
+		issuer:       cfg.Issuer,
+		clientID:     cfg.ClientID,
+		clientSecret: cfg.ClientSecret,
+		redirectURL:  cfg.RedirectURL,
+		client:       &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Name identifies the provider in URLs and linked identities
+func (p *Provider) Name() string {
+	return p.name
+}
+
+// AuthCodeURL returns the provider's login page URL. The state and nonce bind the login to the
+// callback, and the verifier is the PKCE code verifier that will redeem the code.
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error) {
+	md, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	u, err := url.Parse(md.AuthorizationEndpoint)
+	if err != nil {
+		return "", fmt.Errorf("oidc: invalid authorization endpoint: %w", err)
+	}
+	challenge := sha256.Sum256([]byte(verifier))
+	params := u.Query()
+	params.Set("response_type", "code")
+	params.Set("client_id", p.clientID)
+	params.Set("redirect_uri", p.redirectURL)
+	params.Set("scope", scopes)
+	params.Set("state", state)
+	params.Set("nonce", nonce)
+	params.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	params.Set("code_challenge_method", "S256")
+	u.RawQuery = params.Encode()
+	return u.String(), nil
+}
+
+// Exchange redeems an authorization code with its PKCE verifier and returns the identity from the
+// validated ID token, which must carry the nonce the login was started with
+func (p *Provider) Exchange(ctx context.Context, code, verifier, nonce string) (*Identity, error) {
+	md, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.redirectURL)
+	form.Set("code_verifier", verifier)
+	basicAuth := p.clientSecret != "" && !secretPostOnly(md.TokenAuthMethods)
+	if !basicAuth {
+		form.Set("client_id", p.clientID)
+		if p.clientSecret != "" {
+			form.Set("client_secret", p.clientSecret)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, md.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("oidc: token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if basicAuth {
+		req.SetBasicAuth(url.QueryEscape(p.clientID), url.QueryEscape(p.clientSecret))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("oidc: token request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var tokens struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(&tokens); err != nil && resp.StatusCode == http.StatusOK {
+		return nil, fmt.Errorf("oidc: token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("oidc: token request failed with status %d: %s %s", resp.StatusCode, tokens.Error, tokens.ErrorDescription)
+	}
+	if tokens.IDToken == "" {
+		return nil, errors.New("oidc: token response has no ID token")
+	}
+	return p.verifyIDToken(ctx, tokens.IDToken, nonce)
+}
+
+// secretPostOnly reports whether the provider takes the client secret in the request body but not
+// with HTTP basic authentication, which is the default
+func secretPostOnly(methods []string) bool {
+	return slices.Contains(methods, "client_secret_post") && !slices.Contains(methods, "client_secret_basic")
+}
+
+type idTokenClaims struct {
+	jwt.RegisteredClaims
+	Nonce           string    `json:"nonce"`
+	AuthorizedParty string    `json:"azp"`
+	Email           string    `json:"email"`
+	EmailVerified   claimBool `json:"email_verified"`
+	Name            string    `json:"name"`
+}
+
+// claimBool is a boolean claim that some providers send as the string "true" or "false"
+type claimBool bool
+
+func (b *claimBool) UnmarshalJSON(data []byte) error {
+	value := strings.Trim(string(data), `"`)
+	if value == "null" {
+		return nil
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return fmt.Errorf("invalid boolean claim %s", data)
+	}
+	*b = claimBool(parsed)
+	return nil
+}
+
+// verifyIDToken checks the token's signature against the provider's keys, that it was issued by
+// the provider for this client and has not expired, and that it carries the nonce
+func (p *Provider) verifyIDToken(ctx context.Context, raw, nonce string) (*Identity, error) {
+	var claims idTokenClaims
+	_, err := jwt.ParseWithClaims(raw, &claims, func(token *jwt.Token) (any, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.key(ctx, kid)
+	},
+		jwt.WithValidMethods(signingMethods),
+		jwt.WithIssuer(p.issuer),
+		jwt.WithAudience(p.clientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(clockSkew),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("oidc: invalid ID token: %w", err)
+	}
+
+	if claims.Subject == "" {
+		return nil, errors.New("oidc: invalid ID token: no subject")
+	}
+	if len(claims.Audience) > 1 && claims.AuthorizedParty != p.clientID {
+		return nil, errors.New("oidc: invalid ID token: issued to another party")
+	}
+	if nonce == "" || claims.Nonce != nonce {
+		return nil, errors.New("oidc: invalid ID token: nonce does not match")
+	}
+
+	return &Identity{
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: bool(claims.EmailVerified),
+		Name:          claims.Name,
+	}, nil
+}
+
+func (p *Provider) discover(ctx context.Context) (*metadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.discoverLocked(ctx)
+}
+
+// discoverLocked returns the cached discovery document, fetching it when it is missing or stale.
+// The caller must hold p.mu.
+func (p *Provider) discoverLocked(ctx context.Context) (*metadata, error) {
+	if p.metadata != nil && time.Since(p.fetchedAt) < discoveryTTL {
+		return p.metadata, nil
+	}
+
+	var md metadata
+	if err := p.getJSON(ctx, strings.TrimSuffix(p.issuer, "/")+"/.well-known/openid-configuration", &md); err != nil {
+		return nil, fmt.Errorf("oidc: discovery: %w", err)
+	}
+	if md.Issuer != p.issuer {
+		return nil, fmt.Errorf("oidc: discovery document is for issuer %q, not %q", md.Issuer, p.issuer)
+	}
+	if md.AuthorizationEndpoint == "" || md.TokenEndpoint == "" || md.JWKSURI == "" {
+		return nil, errors.New("oidc: discovery document is missing endpoints")
+	}
+
+	p.metadata = &md
+	p.fetchedAt = time.Now()
+	return p.metadata, nil
+}
+
+// key returns the provider's signing key with the ID. The key set is refetched when it is stale or
+// does not have the key, which happens after the provider rotates its keys.
+func (p *Provider) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	md, err := p.discoverLocked(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	_, known := p.keys[kid]
+	stale := time.Since(p.keysFetchedAt) > discoveryTTL
+	if stale || (!known && time.Since(p.keysFetchedAt) > minKeyRefresh) {
+		var set jwk.Set
+		if err := p.getJSON(ctx, md.JWKSURI, &set); err != nil {
+			return nil, fmt.Errorf("oidc: signing keys: %w", err)
+		}
+		p.keys = set.PublicKeys()
+		p.keysFetchedAt = time.Now()
+	}
+
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+	// A token without a key ID can only be checked when the provider has a single key
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("oidc: unknown signing key %q", kid)
+}
+
+func (p *Provider) getJSON(ctx context.Context, endpoint string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: status %d", endpoint, resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(v)
+}
@@ -0,0 +1,346 @@
+package realtime
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// Event types pushed to connected clients
+const (
+	EventMessageNew       = "message.new"
+	EventMessageUpdated   = "message.updated"
+	EventMessageDeleted   = "message.deleted"
+	EventMessageDelivered = "message.delivered"
+	EventMessageRead      = "message.read"
+	EventTyping           = "typing"
+	EventPresence         = "presence"
+
+	EventConversationUpdated = "conversation.updated"
+	EventApplicationStatus   = "application.status"
+
+	// EventResync tells a resuming client that some events are no longer available
+	// and it should reload its state
+	EventResync = "resync"
+)
+
+const (
+	// clientBufferSize is how many events may queue for a slow client before it is dropped
+	clientBufferSize = 64
+	// historySize is how many recent events are kept per user for clients that reconnect
+	historySize = 100
+	// historyTTL is how long an event stays available for clients that reconnect,
+	// and how long after a user's last connection closes their events are still kept
+	historyTTL = 10 * time.Minute
+	// sweepInterval is how often histories of users who went away are released
+	sweepInterval = time.Minute
+)
+
+// Event is the envelope written to live connections. ID increases with every event a user
+// receives, so a reconnecting client can ask for the events it missed.
+type Event struct {
+	ID   uint64 `json:"id,omitempty"`
+	Type string `json:"type"`
+	Data any    `json:"data"`
+}
+
+// ephemeral reports whether an event only matters to clients connected at the time it is sent
+func (e Event) ephemeral() bool {
+	return e.Type == EventTyping || e.Type == EventPresence
+}
+
+// history is the recent events of one user
+type history struct {
+	events []Event
+	sentAt []time.Time
+	// droppedThrough is the highest ID that is no longer available
+	droppedThrough uint64
+	// offlineSince is when the user's last connection closed, zero while they are connected
+	offlineSince time.Time
+}
+
+// expired reports whether the user has had no connection for longer than historyTTL.
+// Nothing is stored for them any more, so a client resuming after that has to resync.
+func (hist *history) expired(now time.Time) bool {
+	return !hist.offlineSince.IsZero() && now.Sub(hist.offlineSince) > historyTTL
+}
+
+// Client is a single live connection (one browser tab, one device) of a user
+type Client struct {
+	UserID int
+	// SessionID is the login session the connection was opened with
+	SessionID int
+	Send      chan Event
+}
+
+// PresenceFunc is called when a user's first connection opens (online=true)
+// or their last connection closes (online=false)
+type PresenceFunc func(userID int, online bool)
+
+// Hub keeps track of live connections grouped by user ID
+type Hub struct {
+	mu         sync.RWMutex
+	clients    map[int]map[*Client]struct{}
+	onPresence PresenceFunc
+
+	// lastID is the ID of the latest event. It starts from the clock so IDs keep
+	// increasing across restarts and IDs from a previous run are known to be lost.
+	lastID  uint64
+	startID uint64
+	// histories holds the recent events of users who are connected or were within historyTTL
+	histories map[int]*history
+}
+
+// NewHub creates an empty Hub and starts releasing histories of users who went away
+func NewHub() *Hub {
+	start := uint64(time.Now().UnixMicro())
+	h := &Hub{
+		clients:   make(map[int]map[*Client]struct{}),
+		lastID:    start,
+		startID:   start,
+		histories: make(map[int]*history),
+	}
+	go h.sweepLoop()
+	return h
+}
+
+func (h *Hub) sweepLoop() {
+	ticker := time.NewTicker(sweepInterval)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		h.sweep(now)
+	}
+}
+
+// sweep drops old events from every history and deletes the histories of users
+// who have been offline for longer than historyTTL
+func (h *Hub) sweep(now time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for userID, hist := range h.histories {
+		h.pruneHistory(userID, hist, now)
+	}
+}
+
+// pruneHistory drops old events from a user's history and deletes it once it is empty
+// and the user has been offline for longer than historyTTL. It reports whether the
+// history is still kept. The caller must hold h.mu.
+func (h *Hub) pruneHistory(userID int, hist *history, now time.Time) bool {
+	if hist.expired(now) {
+		// New events are no longer stored, so whatever is left can't make a resume complete
+		hist.drop(len(hist.events))
+	} else {
+		hist.prune(now)
+	}
+	if len(hist.events) == 0 && hist.expired(now) {
+		delete(h.histories, userID)
+		return false
+	}
+	return true
+}
+
+// OnPresence sets the callback used to announce users going online or offline
+func (h *Hub) OnPresence(fn PresenceFunc) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.onPresence = fn
+}
+
+// Register adds a new connection for the given user and login session
+func (h *Hub) Register(userID, sessionID int) *Client {
+	client, _ := h.register(userID, sessionID, nil)
+	return client
+}
+
+// Resume adds a new connection for a user who last saw the event with the given ID.
+// It returns the events sent since then, which the caller must deliver before reading
+// from Send. When some of them are no longer available it returns a single resync event instead.
+func (h *Hub) Resume(userID, sessionID int, lastEventID uint64) (*Client, []Event) {
+	return h.register(userID, sessionID, &lastEventID)
+}
+
+func (h *Hub) register(userID, sessionID int, lastEventID *uint64) (*Client, []Event) {
+	client := &Client{
+		UserID:    userID,
+		SessionID: sessionID,
+		Send:      make(chan Event, clientBufferSize),
+	}
+
+	h.mu.Lock()
+	var missed []Event
+	if lastEventID != nil {
+		missed = h.eventsSince(userID, *lastEventID)
+	}
+	first := h.clients[userID] == nil
+	if first {
+		h.clients[userID] = make(map[*Client]struct{})
+	}
+	h.clients[userID][client] = struct{}{}
+	if hist := h.histories[userID]; hist != nil {
+		hist.offlineSince = time.Time{}
+	} else {
+		h.histories[userID] = &history{}
+	}
+	onPresence := h.onPresence
+	h.mu.Unlock()
+
+	if first && onPresence != nil {
+		onPresence(userID, true)
+	}
+	return client, missed
+}
+
+// eventsSince returns the stored events of a user newer than lastEventID, or a resync
+// event when some of them have been dropped. The caller must hold h.mu.
+func (h *Hub) eventsSince(userID int, lastEventID uint64) []Event {
+	hist := h.histories[userID]
+	if hist != nil && !h.pruneHistory(userID, hist, time.Now()) {
+		hist = nil
+	}
+	if hist == nil {
+		// The user has had no connection within historyTTL, so events sent to them
+		// since then were not stored. Only a client that saw the latest event is up to date.
+		if lastEventID != h.lastID {
+			return []Event{{ID: h.lastID, Type: EventResync}}
+		}
+		return nil
+	}
+	droppedThrough := max(h.startID, hist.droppedThrough)
+	if lastEventID < droppedThrough || lastEventID > h.lastID {
+		return []Event{{ID: h.lastID, Type: EventResync}}
+	}
+
+	var missed []Event
+	for _, evt := range hist.events {
+		if evt.ID > lastEventID {
+			missed = append(missed, evt)
+		}
+	}
+	return missed
+}
+
+// add stores an event, dropping the oldest ones beyond historySize
+func (hist *history) add(evt Event, now time.Time) {
+	hist.events = append(hist.events, evt)
+	hist.sentAt = append(hist.sentAt, now)
+	hist.prune(now)
+	if n := len(hist.events) - historySize; n > 0 {
+		hist.drop(n)
+	}
+}
+
+// prune drops events older than historyTTL
+func (hist *history) prune(now time.Time) {
+	n := 0
+	for n < len(hist.sentAt) && now.Sub(hist.sentAt[n]) > historyTTL {
+		n++
+	}
+	hist.drop(n)
+}
+
+func (hist *history) drop(n int) {
+	if n == 0 {
+		return
+	}
+	hist.droppedThrough = hist.events[n-1].ID
+	hist.events = slices.Delete(hist.events, 0, n)
+	hist.sentAt = slices.Delete(hist.sentAt, 0, n)
+}
+
+// Unregister removes a connection and closes its Send channel.
+// It is safe to call more than once for the same client.
+func (h *Hub) Unregister(client *Client) {
+	h.mu.Lock()
+	sessions, ok := h.clients[client.UserID]
+	if !ok {
+		h.mu.Unlock()
+		return
+	}
+	if _, ok := sessions[client]; !ok {
+		h.mu.Unlock()
+		return
+	}
+
+	delete(sessions, client)
+	close(client.Send)
+	last := len(sessions) == 0
+	if last {
+		delete(h.clients, client.UserID)
+		if hist := h.histories[client.UserID]; hist != nil {
+			hist.offlineSince = time.Now()
+		}
+	}
+	onPresence := h.onPresence
+	h.mu.Unlock()
+
+	if last && onPresence != nil {
+		onPresence(client.UserID, false)
+	}
+}
+
+// DisconnectSessions closes the connections a user opened with the given login sessions, or all
+// of their connections when no session is given. It is called when sessions are logged out or
+// revoked, so that their connections stop receiving events.
+func (h *Hub) DisconnectSessions(userID int, sessionIDs ...int) {
+	h.mu.RLock()
+	var clients []*Client
+	for client := range h.clients[userID] {
+		if len(sessionIDs) == 0 || slices.Contains(sessionIDs, client.SessionID) {
+			clients = append(clients, client)
+		}
+	}
+	h.mu.RUnlock()
+
+	for _, client := range clients {
+		h.Unregister(client)
+	}
+}
+
+// IsOnline reports whether the user has at least one live connection
+func (h *Hub) IsOnline(userID int) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.clients[userID]) > 0
+}
+
+// SendToUser pushes an event to every live connection of a user.
+// Connections whose buffer is full are considered dead and dropped.
+// Events other than typing and presence are also kept for clients that reconnect,
+// as long as the user has had a connection within historyTTL.
+func (h *Hub) SendToUser(userID int, evt Event) {
+	h.mu.Lock()
+	h.lastID++
+	evt.ID = h.lastID
+	if !evt.ephemeral() {
+		now := time.Now()
+		if hist := h.histories[userID]; hist != nil && h.pruneHistory(userID, hist, now) {
+			hist.add(evt, now)
+		}
+	}
+
+	var stale []*Client
+	for client := range h.clients[userID] {
+		select {
+		case client.Send <- evt:
+		default:
+			stale = append(stale, client)
+		}
+	}
+	h.mu.Unlock()
+
+	for _, client := range stale {
+		h.Unregister(client)
+	}
+}
+
+// SendToUsers pushes the same event to several users, skipping duplicate IDs
+func (h *Hub) SendToUsers(userIDs []int, evt Event) {
+	seen := make(map[int]struct{}, len(userIDs))
+	for _, id := range userIDs {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		h.SendToUser(id, evt)
+	}
+}
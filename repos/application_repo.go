@@ -3,11 +3,15 @@ package repos
 import (
 	"database/sql"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/XORbit01/jobseeker-backend/models"
 )
 
+// ErrApplicationNotFound is returned when no application has the given ID
+var ErrApplicationNotFound = errors.New("application not found")
+
 // ApplicationRepository handles database operations for job applications
 type ApplicationRepository struct {
 	db *sql.DB
@@ -45,15 +49,17 @@ func (r *ApplicationRepository) Create(jobSeekerID int, application models.Appli
 func (r *ApplicationRepository) GetByID(id int) (*models.Application, error) {
 	query := `
 		SELECT a.id, a.job_id, a.job_seeker_id, a.cover_letter, a.status, a.created_at, a.updated_at,
-			   j.title as job_title, e.company_name, js.first_name, js.last_name
+			   j.title as job_title, e.company_name, js.first_name, js.last_name, conv.id
 		FROM applications a
 		JOIN jobs j ON a.job_id = j.id
 		JOIN employer_profiles e ON j.employer_id = e.id
 		JOIN job_seeker_profiles js ON a.job_seeker_id = js.id
+		LEFT JOIN conversations conv ON conv.application_id = a.id
 		WHERE a.id = $1
 	`
 
 	var app models.Application
+	var conversationID sql.NullInt64
 	err := r.db.QueryRow(query, id).Scan(
 		&app.ID,
 		&app.JobID,
@@ -66,6 +72,7 @@ func (r *ApplicationRepository) GetByID(id int) (*models.Application, error) {
 		&app.CompanyName,
 		&app.FirstName,
 		&app.LastName,
+		&conversationID,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -73,10 +80,35 @@ func (r *ApplicationRepository) GetByID(id int) (*models.Application, error) {
 		}
 		return nil, err
 	}
+	if conversationID.Valid {
+		id := int(conversationID.Int64)
+		app.ConversationID = &id
+	}
 
 	return &app, nil
 }
 
+// GetParties returns the job of an application and the user IDs of its candidate and employer
+func (r *ApplicationRepository) GetParties(id int) (jobID, jobSeekerUserID, employerUserID int, err error) {
+	query := `
+		SELECT a.job_id, js.user_id, e.user_id
+		FROM applications a
+		JOIN jobs j ON a.job_id = j.id
+		JOIN employer_profiles e ON j.employer_id = e.id
+		JOIN job_seeker_profiles js ON a.job_seeker_id = js.id
+		WHERE a.id = $1
+	`
+
+	err = r.db.QueryRow(query, id).Scan(&jobID, &jobSeekerUserID, &employerUserID)
+	if err == sql.ErrNoRows {
+		return 0, 0, 0, ErrApplicationNotFound
+	}
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("GetParties: %w", err)
+	}
+	return jobID, jobSeekerUserID, employerUserID, nil
+}
+
 // GetByJobSeekerID retrieves applications by job seeker ID
 func (r *ApplicationRepository) GetByJobSeekerID(jobSeekerID int, page, limit int) ([]*models.Application, int, error) {
 	offset := (page - 1) * limit
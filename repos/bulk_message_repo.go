@@ -0,0 +1,253 @@
+package repos
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/XORbit01/jobseeker-backend/models"
+)
+
+var (
+	ErrBulkMessageNotFound = errors.New("bulk message not found")
+	ErrNoRecipients        = errors.New("no applicants match the selection")
+)
+
+type BulkMessageRepository struct {
+	db *sql.DB
+}
+
+func NewBulkMessageRepository(db *sql.DB) *BulkMessageRepository {
+	return &BulkMessageRepository{db: db}
+}
+
+// Create records a bulk message and its recipients: every applicant of the job, or only those
+// whose application has the given status. It returns ErrNoRecipients when nobody matches.
+func (r *BulkMessageRepository) Create(employerUserID int, input models.BulkMessageInput, body string) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("Create: %w", err)
+	}
+	defer tx.Rollback()
+
+	var id int
+	err = tx.QueryRow(`
+		INSERT INTO bulk_messages (employer_user_id, job_id, application_status, template_id, body)
+		VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, 0), $5)
+		RETURNING id
+	`, employerUserID, input.JobID, input.Status, input.TemplateID, body).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("Create: %w", err)
+	}
+
+	res, err := tx.Exec(`
+		INSERT INTO bulk_message_recipients (bulk_message_id, application_id, user_id)
+		SELECT $1, a.id, js.user_id
+		FROM applications a
+		JOIN job_seeker_profiles js ON js.id = a.job_seeker_id
+		WHERE a.job_id = $2 AND ($3 = '' OR a.status = $3)
+	`, id, input.JobID, input.Status)
+	if err != nil {
+		return 0, fmt.Errorf("Create: %w", err)
+	}
+	total, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("Create: %w", err)
+	}
+	if total == 0 {
+		return 0, ErrNoRecipients
+	}
+
+	if _, err := tx.Exec(`UPDATE bulk_messages SET total_count = $1 WHERE id = $2`, total, id); err != nil {
+		return 0, fmt.Errorf("Create: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("Create: %w", err)
+	}
+	return id, nil
+}
+
+// GetByID retrieves a bulk message with the result for each recipient
+func (r *BulkMessageRepository) GetByID(id int) (*models.BulkMessage, error) {
+	var b models.BulkMessage
+	var applicationStatus sql.NullString
+	var templateID sql.NullInt64
+	var startedAt, finishedAt sql.NullTime
+	err := r.db.QueryRow(`
+		SELECT id, employer_user_id, job_id, application_status, template_id, body, status,
+			total_count, sent_count, failed_count, created_at, started_at, finished_at
+		FROM bulk_messages
+		WHERE id = $1
+	`, id).Scan(&b.ID, &b.EmployerUserID, &b.JobID, &applicationStatus, &templateID, &b.Body, &b.Status,
+		&b.TotalCount, &b.SentCount, &b.FailedCount, &b.CreatedAt, &startedAt, &finishedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrBulkMessageNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("GetByID: %w", err)
+	}
+	b.ApplicationStatus = applicationStatus.String
+	b.TemplateID = nullIntPtr(templateID)
+	if startedAt.Valid {
+		b.StartedAt = &startedAt.Time
+	}
+	if finishedAt.Valid {
+		b.FinishedAt = &finishedAt.Time
+	}
+
+	b.Recipients, err = r.getRecipients(id, "")
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// GetPendingRecipients lists the recipients that have not been processed yet
+func (r *BulkMessageRepository) GetPendingRecipients(id int) ([]models.BulkMessageRecipient, error) {
+	return r.getRecipients(id, models.BulkRecipientPending)
+}
+
+// getRecipients lists the recipients of a bulk message, optionally only those in the given state
+func (r *BulkMessageRepository) getRecipients(id int, status string) ([]models.BulkMessageRecipient, error) {
+	rows, err := r.db.Query(`
+		SELECT application_id, user_id, status, conversation_id, message_id, COALESCE(error, ''), processed_at
+		FROM bulk_message_recipients
+		WHERE bulk_message_id = $1 AND ($2 = '' OR status = $2)
+		ORDER BY application_id
+	`, id, status)
+	if err != nil {
+		return nil, fmt.Errorf("getRecipients: %w", err)
+	}
+	defer rows.Close()
+
+	recipients := make([]models.BulkMessageRecipient, 0)
+	for rows.Next() {
+		var rec models.BulkMessageRecipient
+		var conversationID, messageID sql.NullInt64
+		var processedAt sql.NullTime
+		err := rows.Scan(&rec.ApplicationID, &rec.UserID, &rec.Status, &conversationID, &messageID, &rec.Error, &processedAt)
+		if err != nil {
+			return nil, err
+		}
+		rec.ConversationID = nullIntPtr(conversationID)
+		rec.MessageID = nullIntPtr(messageID)
+		if processedAt.Valid {
+			rec.ProcessedAt = &processedAt.Time
+		}
+		recipients = append(recipients, rec)
+	}
+	return recipients, rows.Err()
+}
+
+// GetUnfinishedIDs lists bulk messages that were not completed, oldest first, so they can be
+// resumed after a restart. It compares against the database clock, so it must run before the
+// server accepts requests for only interrupted ones to be listed.
+func (r *BulkMessageRepository) GetUnfinishedIDs() ([]int, error) {
+	rows, err := r.db.Query(`
+		SELECT id FROM bulk_messages WHERE status <> $1 AND created_at < NOW() ORDER BY id
+	`, models.BulkMessageCompleted)
+	if err != nil {
+		return nil, fmt.Errorf("GetUnfinishedIDs: %w", err)
+	}
+	defer rows.Close()
+
+	ids := make([]int, 0)
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// MarkStarted moves a bulk message to the running state
+func (r *BulkMessageRepository) MarkStarted(id int) error {
+	_, err := r.db.Exec(`
+		UPDATE bulk_messages SET status = $1, started_at = COALESCE(started_at, NOW()) WHERE id = $2
+	`, models.BulkMessageRunning, id)
+	if err != nil {
+		return fmt.Errorf("MarkStarted: %w", err)
+	}
+	return nil
+}
+
+// ClaimRecipient marks a pending recipient as being sent to. It reports false when the
+// recipient was already claimed, in which case nothing must be sent.
+func (r *BulkMessageRepository) ClaimRecipient(id, applicationID int) (bool, error) {
+	res, err := r.db.Exec(`
+		UPDATE bulk_message_recipients SET status = $1, processed_at = NOW()
+		WHERE bulk_message_id = $2 AND application_id = $3 AND status = $4
+	`, models.BulkRecipientSending, id, applicationID, models.BulkRecipientPending)
+	if err != nil {
+		return false, fmt.Errorf("ClaimRecipient: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("ClaimRecipient: %w", err)
+	}
+	return n > 0, nil
+}
+
+// FailInterrupted records the recipients that were claimed but never got a result, because
+// the server stopped while sending to them. They are not sent to again.
+func (r *BulkMessageRepository) FailInterrupted(id int) error {
+	_, err := r.db.Exec(`
+		WITH failed AS (
+			UPDATE bulk_message_recipients SET status = $1, error = $2, processed_at = NOW()
+			WHERE bulk_message_id = $3 AND status = $4
+			RETURNING 1
+		)
+		UPDATE bulk_messages SET failed_count = failed_count + (SELECT COUNT(*) FROM failed)
+		WHERE id = $3
+	`, models.BulkRecipientFailed, models.BulkErrorInterrupted, id, models.BulkRecipientSending)
+	if err != nil {
+		return fmt.Errorf("FailInterrupted: %w", err)
+	}
+	return nil
+}
+
+// RecordResult stores the outcome for a claimed recipient and updates the progress counters
+func (r *BulkMessageRepository) RecordResult(id int, rec models.BulkMessageRecipient) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("RecordResult: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`
+		UPDATE bulk_message_recipients
+		SET status = $1, conversation_id = $2, message_id = $3, error = NULLIF($4, ''), processed_at = NOW()
+		WHERE bulk_message_id = $5 AND application_id = $6 AND status = $7
+	`, rec.Status, rec.ConversationID, rec.MessageID, rec.Error, id, rec.ApplicationID, models.BulkRecipientSending)
+	if err != nil {
+		return fmt.Errorf("RecordResult: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		// Already recorded
+		return nil
+	}
+
+	_, err = tx.Exec(`
+		UPDATE bulk_messages
+		SET sent_count = sent_count + CASE WHEN $1 = 'sent' THEN 1 ELSE 0 END,
+			failed_count = failed_count + CASE WHEN $1 = 'failed' THEN 1 ELSE 0 END
+		WHERE id = $2
+	`, rec.Status, id)
+	if err != nil {
+		return fmt.Errorf("RecordResult: %w", err)
+	}
+	return tx.Commit()
+}
+
+// MarkCompleted moves a bulk message to the completed state
+func (r *BulkMessageRepository) MarkCompleted(id int) error {
+	_, err := r.db.Exec(`
+		UPDATE bulk_messages SET status = $1, finished_at = NOW() WHERE id = $2
+	`, models.BulkMessageCompleted, id)
+	if err != nil {
+		return fmt.Errorf("MarkCompleted: %w", err)
+	}
+	return nil
+}
@@ -2,9 +2,23 @@ package repos
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"html"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/XORbit01/jobseeker-backend/models"
+	"github.com/lib/pq"
+)
+
+var (
+	ErrMessageNotFound   = errors.New("message not found")
+	ErrNotMessageSender  = errors.New("only the sender can change this message")
+	ErrEditWindowExpired = errors.New("the time window for changing this message has passed")
+	ErrMessageDeleted    = errors.New("message has been deleted")
+	ErrBlocked           = errors.New("messaging between these users is blocked")
 )
 
 type ChatRepository struct {
@@ -15,23 +29,27 @@ func NewChatRepository(db *sql.DB) *ChatRepository {
 	return &ChatRepository{db: db}
 }
 
-// Get or create a conversation between two users (order enforced)
+// Get or create the general conversation between two users (order enforced).
+// Conversations tied to a job or application, and groups, are separate threads.
 func (r *ChatRepository) getOrCreateConversation(userA, userB int) (int, error) {
 	if userA > userB {
 		userA, userB = userB, userA
 	}
+	directKey := fmt.Sprintf("%d:%d", userA, userB)
 
 	var conversationID int
 	err := r.db.QueryRow(`
-		SELECT id FROM conversations
-		WHERE participant_one_id = $1 AND participant_two_id = $2
-	`, userA, userB).Scan(&conversationID)
+		SELECT id FROM conversations WHERE direct_key = $1
+	`, directKey).Scan(&conversationID)
 
 	if err == sql.ErrNoRows {
-		err = r.db.QueryRow(`
-			INSERT INTO conversations (participant_one_id, participant_two_id)
-			VALUES ($1, $2) RETURNING id
-		`, userA, userB).Scan(&conversationID)
+		conversationID, err = r.insertConversation(newConversation{directKey: directKey}, []int{userA, userB})
+		if err == sql.ErrNoRows {
+			// Created concurrently by the other participant
+			err = r.db.QueryRow(`
+				SELECT id FROM conversations WHERE direct_key = $1
+			`, directKey).Scan(&conversationID)
+		}
 	}
 	if err != nil {
 		return 0, fmt.Errorf("getOrCreateConversation: %w", err)
@@ -40,151 +58,1120 @@ func (r *ChatRepository) getOrCreateConversation(userA, userB int) (int, error)
 	return conversationID, nil
 }
 
-// SaveMessage inserts a new message and returns its ID
+// newConversation describes a conversation to insert. Zero values are stored as NULL.
+type newConversation struct {
+	isGroup       bool
+	title         string
+	createdBy     int
+	directKey     string
+	jobID         int
+	applicationID int
+}
+
+// insertConversation creates a conversation and its members in one transaction.
+// It returns sql.ErrNoRows when the direct key or application already has a conversation.
+func (r *ChatRepository) insertConversation(conv newConversation, memberIDs []int) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("insertConversation: %w", err)
+	}
+	defer tx.Rollback()
+
+	var id int
+	err = tx.QueryRow(`
+		INSERT INTO conversations (is_group, title, created_by, direct_key, job_id, application_id)
+		VALUES ($1, NULLIF($2, ''), NULLIF($3, 0), NULLIF($4, ''), NULLIF($5, 0), NULLIF($6, 0))
+		ON CONFLICT DO NOTHING
+		RETURNING id
+	`, conv.isGroup, conv.title, conv.createdBy, conv.directKey, conv.jobID, conv.applicationID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, err
+	}
+	if err != nil {
+		return 0, fmt.Errorf("insertConversation: %w", err)
+	}
+
+	if err := addMembers(tx, id, conv.createdBy, memberIDs); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("insertConversation: %w", err)
+	}
+	return id, nil
+}
+
+// addMembers inserts participants, skipping users who are already members
+func addMembers(tx *sql.Tx, conversationID, addedBy int, userIDs []int) error {
+	ids := make([]int64, len(userIDs))
+	for i, id := range userIDs {
+		ids[i] = int64(id)
+	}
+
+	_, err := tx.Exec(`
+		INSERT INTO conversation_participants (conversation_id, user_id, added_by)
+		SELECT $1, u.id, NULLIF($3, 0)
+		FROM UNNEST($2::int[]) AS u(id)
+		ON CONFLICT (conversation_id, user_id) DO NOTHING
+	`, conversationID, pq.Array(ids), addedBy)
+	if err != nil {
+		return fmt.Errorf("addMembers: %w", err)
+	}
+	return nil
+}
+
+// GetUserRole returns the role of a user, or ErrUserNotFound
+func (r *ChatRepository) GetUserRole(userID int) (string, error) {
+	var role string
+	err := r.db.QueryRow(`SELECT role FROM users WHERE id = $1`, userID).Scan(&role)
+	if err == sql.ErrNoRows {
+		return "", ErrUserNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("GetUserRole: %w", err)
+	}
+	return role, nil
+}
+
+// HasConversationBetween reports whether the two users already share a one-to-one conversation.
+// Being in the same group does not count.
+func (r *ChatRepository) HasConversationBetween(userA, userB int) (bool, error) {
+	var exists bool
+	err := r.db.QueryRow(`
+		SELECT EXISTS(
+			SELECT 1
+			FROM conversation_participants a
+			JOIN conversation_participants b ON b.conversation_id = a.conversation_id
+			JOIN conversations c ON c.id = a.conversation_id
+			WHERE a.user_id = $1 AND b.user_id = $2 AND NOT c.is_group
+		)
+	`, userA, userB).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("HasConversationBetween: %w", err)
+	}
+	return exists, nil
+}
+
+// CanEmployerContact reports whether the candidate applied to one of the employer's jobs
+// or opted into being discoverable
+func (r *ChatRepository) CanEmployerContact(employerUserID, jobSeekerUserID int) (bool, error) {
+	var allowed bool
+	err := r.db.QueryRow(`
+		SELECT EXISTS(
+			SELECT 1
+			FROM applications a
+			JOIN jobs j ON j.id = a.job_id
+			JOIN employer_profiles ep ON ep.id = j.employer_id
+			JOIN job_seeker_profiles js ON js.id = a.job_seeker_id
+			WHERE ep.user_id = $1 AND js.user_id = $2
+		) OR EXISTS(
+			SELECT 1 FROM job_seeker_profiles
+			WHERE user_id = $2 AND discoverable
+		)
+	`, employerUserID, jobSeekerUserID).Scan(&allowed)
+	if err != nil {
+		return false, fmt.Errorf("CanEmployerContact: %w", err)
+	}
+	return allowed, nil
+}
+
+// IsBlockedBetween reports whether either user has blocked the other
+func (r *ChatRepository) IsBlockedBetween(userA, userB int) (bool, error) {
+	var blocked bool
+	err := r.db.QueryRow(`
+		SELECT EXISTS(
+			SELECT 1 FROM user_blocks
+			WHERE (blocker_id = $1 AND blocked_id = $2) OR (blocker_id = $2 AND blocked_id = $1)
+		)
+	`, userA, userB).Scan(&blocked)
+	if err != nil {
+		return false, fmt.Errorf("IsBlockedBetween: %w", err)
+	}
+	return blocked, nil
+}
+
+// SaveMessage inserts a new message together with its attachments and returns its ID.
+// The message goes to msg.ConversationID when set, otherwise to the general conversation
+// between sender and receiver. It returns ErrBlocked when either user has blocked the other.
 func (r *ChatRepository) SaveMessage(msg models.Message) (int, error) {
-	conversationID, err := r.getOrCreateConversation(msg.SenderID, msg.ReceiverID)
+	blocked, err := r.IsBlockedBetween(msg.SenderID, msg.ReceiverID)
 	if err != nil {
 		return 0, err
 	}
+	if blocked {
+		return 0, ErrBlocked
+	}
+
+	conversationID := msg.ConversationID
+	if conversationID == 0 {
+		conversationID, err = r.getOrCreateConversation(msg.SenderID, msg.ReceiverID)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("could not save message: %w", err)
+	}
+	defer tx.Rollback()
 
 	query := `
 		INSERT INTO messages (conversation_id, sender_id, content)
 		VALUES ($1, $2, $3) RETURNING id
 	`
 	var messageID int
-	err = r.db.QueryRow(query, conversationID, msg.SenderID, msg.Content).Scan(&messageID)
+	err = tx.QueryRow(query, conversationID, msg.SenderID, msg.Content).Scan(&messageID)
 	if err != nil {
 		return 0, fmt.Errorf("could not save message: %w", err)
 	}
+
+	for _, a := range msg.Attachments {
+		_, err := tx.Exec(`
+			INSERT INTO message_attachments (message_id, file_name, mime_type, size_bytes, url)
+			VALUES ($1, $2, $3, $4, $5)
+		`, messageID, a.FileName, a.MimeType, a.SizeBytes, a.URL)
+		if err != nil {
+			return 0, fmt.Errorf("could not save attachment: %w", err)
+		}
+	}
+
+	// New activity brings an archived conversation back to the inbox, unless that member muted it
+	_, err = tx.Exec(`
+		UPDATE conversation_participants SET archived_at = NULL
+		WHERE conversation_id = $1 AND archived_at IS NOT NULL AND NOT muted
+	`, conversationID)
+	if err != nil {
+		return 0, fmt.Errorf("could not save message: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("could not save message: %w", err)
+	}
 	return messageID, nil
 }
 
+// loadAttachments fills the Attachments field of the given messages
+func (r *ChatRepository) loadAttachments(messages []models.Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, len(messages))
+	byID := make(map[int]*models.Message, len(messages))
+	for i := range messages {
+		ids[i] = int64(messages[i].ID)
+		byID[messages[i].ID] = &messages[i]
+	}
+
+	rows, err := r.db.Query(`
+		SELECT id, message_id, file_name, mime_type, size_bytes, url, created_at
+		FROM message_attachments
+		WHERE message_id = ANY($1)
+		ORDER BY id
+	`, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("loadAttachments: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var a models.MessageAttachment
+		if err := rows.Scan(&a.ID, &a.MessageID, &a.FileName, &a.MimeType, &a.SizeBytes, &a.URL, &a.CreatedAt); err != nil {
+			return err
+		}
+		// Attachments of deleted messages are kept for moderation but not shown
+		if msg, ok := byID[a.MessageID]; ok && msg.DeletedAt == nil {
+			msg.Attachments = append(msg.Attachments, a)
+		}
+	}
+	return rows.Err()
+}
+
+// messageColumns is the column list read by scanMessage
+const messageColumns = `id, conversation_id, sender_id, content, created_at, is_read, delivered_at, read_at, edited_at, deleted_at`
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanMessage reads a row selected with messageColumns
+func scanMessage(row rowScanner) (models.Message, error) {
+	var msg models.Message
+	var deliveredAt, readAt, editedAt, deletedAt sql.NullTime
+	err := row.Scan(&msg.ID, &msg.ConversationID, &msg.SenderID, &msg.Content, &msg.Timestamp, &msg.Read,
+		&deliveredAt, &readAt, &editedAt, &deletedAt)
+	if err != nil {
+		return msg, err
+	}
+	if deliveredAt.Valid {
+		msg.DeliveredAt = &deliveredAt.Time
+	}
+	if readAt.Valid {
+		msg.ReadAt = &readAt.Time
+	}
+	if editedAt.Valid {
+		msg.EditedAt = &editedAt.Time
+	}
+	if deletedAt.Valid {
+		msg.DeletedAt = &deletedAt.Time
+	}
+	return msg, nil
+}
+
 // GetMessageByID retrieves a single message by ID
 func (r *ChatRepository) GetMessageByID(id int) (*models.Message, error) {
-	query := `
-		SELECT id, conversation_id, sender_id, content, created_at, is_read
-		FROM messages WHERE id = $1
-	`
-	var msg models.Message
-	err := r.db.QueryRow(query, id).Scan(
-		&msg.ID, &msg.ConversationID, &msg.SenderID, &msg.Content, &msg.Timestamp, &msg.Read,
-	)
+	query := `SELECT ` + messageColumns + ` FROM messages WHERE id = $1`
+	msg, err := scanMessage(r.db.QueryRow(query, id))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
 	if err != nil {
 		return nil, fmt.Errorf("GetMessageByID: %w", err)
 	}
-	return &msg, nil
+
+	messages := []models.Message{msg}
+	if err := r.loadAttachments(messages); err != nil {
+		return nil, err
+	}
+	return &messages[0], nil
+}
+
+// EditMessage replaces the content of a message, keeping the previous text as a revision.
+// Only the sender may edit, and only within window of sending.
+func (r *ChatRepository) EditMessage(messageID, senderID int, content string, window time.Duration) error {
+	return r.reviseMessage(messageID, senderID, window, "edit", `
+		UPDATE messages SET content = $1, edited_at = NOW() WHERE id = $2
+	`, content)
+}
+
+// DeleteMessage turns a message into a tombstone, keeping its text as a revision.
+// Only the sender may delete, and only within window of sending.
+func (r *ChatRepository) DeleteMessage(messageID, senderID int, window time.Duration) error {
+	return r.reviseMessage(messageID, senderID, window, "delete", `
+		UPDATE messages SET content = $1, deleted_at = NOW() WHERE id = $2
+	`, "")
+}
+
+// reviseMessage checks ownership and the time window, stores the current content as a revision
+// and applies the update in a single transaction
+func (r *ChatRepository) reviseMessage(messageID, senderID int, window time.Duration, action, update, content string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("reviseMessage: %w", err)
+	}
+	defer tx.Rollback()
+
+	var ownerID int
+	var current string
+	var createdAt time.Time
+	var deletedAt sql.NullTime
+	err = tx.QueryRow(`
+		SELECT sender_id, content, created_at, deleted_at
+		FROM messages WHERE id = $1
+		FOR UPDATE
+	`, messageID).Scan(&ownerID, &current, &createdAt, &deletedAt)
+	if err == sql.ErrNoRows {
+		return ErrMessageNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("reviseMessage: %w", err)
+	}
+
+	switch {
+	case ownerID != senderID:
+		return ErrNotMessageSender
+	case deletedAt.Valid:
+		return ErrMessageDeleted
+	case time.Since(createdAt) > window:
+		return ErrEditWindowExpired
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO message_revisions (message_id, content, action)
+		VALUES ($1, $2, $3)
+	`, messageID, current, action)
+	if err != nil {
+		return fmt.Errorf("reviseMessage: %w", err)
+	}
+
+	if _, err := tx.Exec(update, content, messageID); err != nil {
+		return fmt.Errorf("reviseMessage: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// GetMessageRevisions lists earlier versions of a message, oldest first
+func (r *ChatRepository) GetMessageRevisions(messageID int) ([]models.MessageRevision, error) {
+	rows, err := r.db.Query(`
+		SELECT id, message_id, content, action, created_at
+		FROM message_revisions
+		WHERE message_id = $1
+		ORDER BY id
+	`, messageID)
+	if err != nil {
+		return nil, fmt.Errorf("GetMessageRevisions: %w", err)
+	}
+	defer rows.Close()
+
+	revisions := make([]models.MessageRevision, 0)
+	for rows.Next() {
+		var rev models.MessageRevision
+		if err := rows.Scan(&rev.ID, &rev.MessageID, &rev.Content, &rev.Action, &rev.CreatedAt); err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, rev)
+	}
+	return revisions, rows.Err()
+}
+
+// GetParticipantIDs returns the user IDs taking part in a conversation, in joining order.
+// The result is empty when the conversation does not exist.
+func (r *ChatRepository) GetParticipantIDs(conversationID int) ([]int, error) {
+	rows, err := r.db.Query(`
+		SELECT user_id FROM conversation_participants
+		WHERE conversation_id = $1
+		ORDER BY joined_at, user_id
+	`, conversationID)
+	if err != nil {
+		return nil, fmt.Errorf("GetParticipantIDs: %w", err)
+	}
+	defer rows.Close()
+
+	ids := make([]int, 0, 2)
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// IsParticipant checks whether the user takes part in a conversation
+func (r *ChatRepository) IsParticipant(conversationID, userID int) (bool, error) {
+	var member bool
+	err := r.db.QueryRow(`
+		SELECT EXISTS(
+			SELECT 1 FROM conversation_participants
+			WHERE conversation_id = $1 AND user_id = $2
+		)
+	`, conversationID, userID).Scan(&member)
+	if err != nil {
+		return false, fmt.Errorf("IsParticipant: %w", err)
+	}
+	return member, nil
+}
+
+// GetContactIDs returns every user who shares a conversation with the given user
+func (r *ChatRepository) GetContactIDs(userID int) ([]int, error) {
+	rows, err := r.db.Query(`
+		SELECT DISTINCT other.user_id
+		FROM conversation_participants me
+		JOIN conversation_participants other
+			ON other.conversation_id = me.conversation_id AND other.user_id <> me.user_id
+		WHERE me.user_id = $1
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("GetContactIDs: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// UpdateLastSeen stores when the user's last live connection closed
+func (r *ChatRepository) UpdateLastSeen(userID int, seenAt time.Time) error {
+	_, err := r.db.Exec(`UPDATE users SET last_seen_at = $1 WHERE id = $2`, seenAt, userID)
+	if err != nil {
+		return fmt.Errorf("UpdateLastSeen: %w", err)
+	}
+	return nil
+}
+
+// cursorClause builds the filter, ordering and limit shared by cursor-paginated queries.
+// One extra row is requested so callers can tell whether another page exists.
+// The returned bool reports whether rows come back in descending order.
+func cursorClause(column string, page models.CursorParams, args []any) (string, []any, bool) {
+	var clause string
+	descending := true
+
+	switch {
+	case page.After > 0:
+		args = append(args, page.After)
+		clause = fmt.Sprintf("AND %s > $%d", column, len(args))
+		descending = false
+	case page.Before > 0:
+		args = append(args, page.Before)
+		clause = fmt.Sprintf("AND %s < $%d", column, len(args))
+	}
+
+	order := "DESC"
+	if !descending {
+		order = "ASC"
+	}
+
+	args = append(args, page.Limit+1)
+	clause += fmt.Sprintf(" ORDER BY %s %s LIMIT $%d", column, order, len(args))
+	return clause, args, descending
 }
 
-// GetConversationsForUser lists all conversations the user is in
-func (r *ChatRepository) GetConversationsForUser(userID int) ([]models.Conversation, error) {
+// conversationColumns is the column list read by scanConversation, for a conversations table aliased c
+const conversationColumns = `c.id, c.is_group, COALESCE(c.title, ''), c.created_by, c.job_id, c.application_id, c.created_at`
+
+// scanConversation reads a row selected with conversationColumns
+func scanConversation(row rowScanner) (models.Conversation, error) {
+	var c models.Conversation
+	var createdBy, jobID, applicationID sql.NullInt64
+	if err := row.Scan(&c.ID, &c.IsGroup, &c.Title, &createdBy, &jobID, &applicationID, &c.CreatedAt); err != nil {
+		return c, err
+	}
+	c.CreatedBy = nullIntPtr(createdBy)
+	c.JobID = nullIntPtr(jobID)
+	c.ApplicationID = nullIntPtr(applicationID)
+	return c, nil
+}
+
+// nullIntPtr converts a nullable integer column to *int
+func nullIntPtr(v sql.NullInt64) *int {
+	if !v.Valid {
+		return nil
+	}
+	n := int(v.Int64)
+	return &n
+}
+
+// loadParticipants fills the ParticipantIDs field of the given conversations
+func (r *ChatRepository) loadParticipants(convs []*models.Conversation) error {
+	if len(convs) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, len(convs))
+	byID := make(map[int]*models.Conversation, len(convs))
+	for i, c := range convs {
+		ids[i] = int64(c.ID)
+		c.ParticipantIDs = make([]int, 0, 2)
+		byID[c.ID] = c
+	}
+
+	rows, err := r.db.Query(`
+		SELECT conversation_id, user_id
+		FROM conversation_participants
+		WHERE conversation_id = ANY($1)
+		ORDER BY joined_at, user_id
+	`, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("loadParticipants: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var convID, userID int
+		if err := rows.Scan(&convID, &userID); err != nil {
+			return err
+		}
+		if c, ok := byID[convID]; ok {
+			c.ParticipantIDs = append(c.ParticipantIDs, userID)
+		}
+	}
+	return rows.Err()
+}
+
+// GetConversation retrieves a conversation with its participants, or nil when it does not exist
+func (r *ChatRepository) GetConversation(id int) (*models.Conversation, error) {
+	c, err := scanConversation(r.db.QueryRow(`
+		SELECT `+conversationColumns+` FROM conversations c WHERE c.id = $1
+	`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("GetConversation: %w", err)
+	}
+	if err := r.loadParticipants([]*models.Conversation{&c}); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// GetConversationByApplication returns the conversation opened for an application, or nil
+func (r *ChatRepository) GetConversationByApplication(applicationID int) (*models.Conversation, error) {
+	var id int
+	err := r.db.QueryRow(`
+		SELECT id FROM conversations WHERE application_id = $1
+	`, applicationID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("GetConversationByApplication: %w", err)
+	}
+	return r.GetConversation(id)
+}
+
+// GetOrCreateApplicationConversation returns the conversation of an application, creating it
+// with the given members if needed. created reports whether it was just created.
+func (r *ChatRepository) GetOrCreateApplicationConversation(applicationID, jobID, createdBy int, memberIDs []int) (conv *models.Conversation, created bool, err error) {
+	id, err := r.insertConversation(newConversation{
+		createdBy:     createdBy,
+		jobID:         jobID,
+		applicationID: applicationID,
+	}, memberIDs)
+	if err == sql.ErrNoRows {
+		// Someone else opened it first
+		existing, err := r.GetConversationByApplication(applicationID)
+		return existing, false, err
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("GetOrCreateApplicationConversation: %w", err)
+	}
+
+	conv, err = r.GetConversation(id)
+	return conv, true, err
+}
+
+// CreateGroupConversation creates a titled group with the creator and the given members
+func (r *ChatRepository) CreateGroupConversation(creatorID int, title string, memberIDs []int) (*models.Conversation, error) {
+	members := append([]int{creatorID}, memberIDs...)
+	id, err := r.insertConversation(newConversation{
+		isGroup:   true,
+		title:     title,
+		createdBy: creatorID,
+	}, members)
+	if err != nil {
+		return nil, fmt.Errorf("CreateGroupConversation: %w", err)
+	}
+	return r.GetConversation(id)
+}
+
+// AddParticipants adds members to a conversation. A conversation that gains members
+// becomes a group, so it no longer counts as a one-to-one thread.
+func (r *ChatRepository) AddParticipants(conversationID, addedBy int, userIDs []int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("AddParticipants: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE conversations SET is_group = TRUE WHERE id = $1`, conversationID); err != nil {
+		return fmt.Errorf("AddParticipants: %w", err)
+	}
+	if err := addMembers(tx, conversationID, addedBy, userIDs); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// RemoveParticipant removes a member from a conversation. It reports false when the user was not a member.
+func (r *ChatRepository) RemoveParticipant(conversationID, userID int) (bool, error) {
+	res, err := r.db.Exec(`
+		DELETE FROM conversation_participants
+		WHERE conversation_id = $1 AND user_id = $2
+	`, conversationID, userID)
+	if err != nil {
+		return false, fmt.Errorf("RemoveParticipant: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// GetConversationsForUser lists a page of conversations the user is in, newest first
+func (r *ChatRepository) GetConversationsForUser(userID int, page models.CursorParams) (*models.ConversationPage, error) {
+	tail, args, descending := cursorClause("c.id", page, []any{userID})
 	query := `
-		SELECT id, participant_one_id, participant_two_id, created_at
-		FROM conversations
-		WHERE participant_one_id = $1 OR participant_two_id = $1
-		ORDER BY created_at DESC
-	`
-	rows, err := r.db.Query(query, userID)
+		SELECT ` + conversationColumns + `
+		FROM conversations c
+		WHERE c.id IN (SELECT conversation_id FROM conversation_participants WHERE user_id = $1)
+	` + tail
+
+	rows, err := r.db.Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("GetConversationsForUser: %w", err)
 	}
 	defer rows.Close()
 
-	var conversations []models.Conversation
+	conversations := make([]models.Conversation, 0)
 	for rows.Next() {
-		var c models.Conversation
-		if err := rows.Scan(&c.ID, &c.ParticipantOneID, &c.ParticipantTwoID, &c.CreatedAt); err != nil {
+		c, err := scanConversation(rows)
+		if err != nil {
 			return nil, err
 		}
 		conversations = append(conversations, c)
 	}
-	return conversations, nil
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	result := &models.ConversationPage{}
+	if len(conversations) > page.Limit {
+		conversations = conversations[:page.Limit]
+		next := conversations[len(conversations)-1].ID
+		result.NextCursor = &next
+	}
+	if !descending {
+		slices.Reverse(conversations)
+	}
+
+	ptrs := make([]*models.Conversation, len(conversations))
+	for i := range conversations {
+		ptrs[i] = &conversations[i]
+	}
+	if err := r.loadParticipants(ptrs); err != nil {
+		return nil, err
+	}
+	result.Conversations = conversations
+	return result, nil
 }
 
-// GetMessagesInConversation retrieves all messages from a conversation (if user is a participant)
-func (r *ChatRepository) GetMessagesInConversation(conversationID, userID int) ([]models.Message, error) {
+// GetMessagesInConversation retrieves a page of messages from a conversation (if user is a participant).
+// Without a cursor the most recent messages are returned. Messages are always in ascending order.
+func (r *ChatRepository) GetMessagesInConversation(conversationID, userID int, page models.CursorParams) (*models.MessagePage, error) {
 	// Verify access
-	var count int
-	err := r.db.QueryRow(`
-		SELECT COUNT(*) FROM conversations
-		WHERE id = $1 AND (participant_one_id = $2 OR participant_two_id = $2)
-	`, conversationID, userID).Scan(&count)
+	member, err := r.IsParticipant(conversationID, userID)
 	if err != nil {
 		return nil, fmt.Errorf("GetMessagesInConversation auth check: %w", err)
 	}
-	if count == 0 {
+	if !member {
 		return nil, sql.ErrNoRows
 	}
 
+	tail, args, descending := cursorClause("id", page, []any{conversationID})
 	rows, err := r.db.Query(`
-		SELECT id, conversation_id, sender_id, content, created_at, is_read
+		SELECT `+messageColumns+`
 		FROM messages
 		WHERE conversation_id = $1
-		ORDER BY created_at ASC
-	`, conversationID)
+	`+tail, args...)
 	if err != nil {
 		return nil, fmt.Errorf("GetMessagesInConversation: %w", err)
 	}
 	defer rows.Close()
 
-	var messages []models.Message
+	messages := make([]models.Message, 0)
 	for rows.Next() {
-		var msg models.Message
-		if err := rows.Scan(&msg.ID, &msg.ConversationID, &msg.SenderID, &msg.Content, &msg.Timestamp, &msg.Read); err != nil {
+		msg, err := scanMessage(rows)
+		if err != nil {
 			return nil, err
 		}
 		messages = append(messages, msg)
 	}
-	return messages, nil
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	result := &models.MessagePage{}
+	if len(messages) > page.Limit {
+		messages = messages[:page.Limit]
+		next := messages[len(messages)-1].ID
+		result.NextCursor = &next
+	}
+	if descending {
+		slices.Reverse(messages)
+	}
+	if err := r.loadAttachments(messages); err != nil {
+		return nil, err
+	}
+	result.Messages = messages
+	return result, nil
+}
+
+// MarkMessageDelivered records that a message reached a live connection of its recipient
+func (r *ChatRepository) MarkMessageDelivered(messageID int) (*time.Time, error) {
+	var deliveredAt time.Time
+	err := r.db.QueryRow(`
+		UPDATE messages
+		SET delivered_at = NOW()
+		WHERE id = $1 AND delivered_at IS NULL
+		RETURNING delivered_at
+	`, messageID).Scan(&deliveredAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("MarkMessageDelivered: %w", err)
+	}
+	return &deliveredAt, nil
 }
 
-// MarkMessagesAsReadInConversation sets unread messages in a conversation as read
-func (r *ChatRepository) MarkMessagesAsReadInConversation(conversationID, userID int) error {
-	// Only update messages not sent by current user
-	_, err := r.db.Exec(`
+// MarkMessagesDeliveredInConversation records delivery of every message the user has not received yet.
+// It returns nil when nothing changed.
+func (r *ChatRepository) MarkMessagesDeliveredInConversation(conversationID, userID int) (*models.ReceiptUpdate, error) {
+	return r.updateReceipts(`
 		UPDATE messages
-		SET is_read = TRUE
-		WHERE conversation_id = $1 AND sender_id != $2 AND is_read = FALSE
+		SET delivered_at = NOW()
+		WHERE conversation_id = $1 AND sender_id != $2 AND delivered_at IS NULL
+		RETURNING id, delivered_at
 	`, conversationID, userID)
+}
 
+// MarkMessagesAsReadInConversation moves the user's read position forward and sets the messages as read.
+// Each member keeps their own position; in groups a message's read_at records its first reader.
+// When upToMessageID is positive only messages up to and including that ID are marked.
+// It returns nil when nothing changed.
+func (r *ChatRepository) MarkMessagesAsReadInConversation(conversationID, userID, upToMessageID int) (*models.ReceiptUpdate, error) {
+	// Only messages not sent by current user count
+	var lastRead int
+	err := r.db.QueryRow(`
+		UPDATE conversation_participants cp
+		SET last_read_message_id = latest.id
+		FROM (
+			SELECT MAX(id) AS id FROM messages
+			WHERE conversation_id = $1 AND sender_id != $2 AND ($3 = 0 OR id <= $3)
+		) latest
+		WHERE cp.conversation_id = $1 AND cp.user_id = $2
+			AND latest.id > COALESCE(cp.last_read_message_id, 0)
+		RETURNING cp.last_read_message_id
+	`, conversationID, userID, upToMessageID).Scan(&lastRead)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
 	if err != nil {
-		return fmt.Errorf("MarkMessagesAsReadInConversation: %w", err)
+		return nil, fmt.Errorf("MarkMessagesAsReadInConversation: %w", err)
 	}
-	return nil
+
+	now := time.Now()
+	_, err = r.db.Exec(`
+		UPDATE messages
+		SET is_read = TRUE, read_at = $4, delivered_at = COALESCE(delivered_at, $4)
+		WHERE conversation_id = $1 AND sender_id != $2 AND read_at IS NULL AND id <= $3
+	`, conversationID, userID, lastRead, now)
+	if err != nil {
+		return nil, fmt.Errorf("MarkMessagesAsReadInConversation: %w", err)
+	}
+
+	return &models.ReceiptUpdate{
+		ConversationID: conversationID,
+		UserID:         userID,
+		UpToMessageID:  lastRead,
+		At:             now,
+	}, nil
 }
 
-// GetConversationsWithStatsForUser returns all conversations + message counts for a user
-func (r *ChatRepository) GetConversationsWithStatsForUser(userID int) ([]models.ConversationWithStats, int, error) {
+// updateReceipts runs a receipt UPDATE ... RETURNING id, timestamp and summarises the affected rows
+func (r *ChatRepository) updateReceipts(query string, conversationID, userID int, extra ...any) (*models.ReceiptUpdate, error) {
+	args := append([]any{conversationID, userID}, extra...)
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("updateReceipts: %w", err)
+	}
+	defer rows.Close()
+
+	var receipt *models.ReceiptUpdate
+	for rows.Next() {
+		var id int
+		var at time.Time
+		if err := rows.Scan(&id, &at); err != nil {
+			return nil, err
+		}
+		if receipt == nil {
+			receipt = &models.ReceiptUpdate{ConversationID: conversationID, UserID: userID}
+		}
+		if id > receipt.UpToMessageID {
+			receipt.UpToMessageID = id
+			receipt.At = at
+		}
+	}
+	return receipt, rows.Err()
+}
+
+// GetConversationsWithStatsForUser returns a page of conversations + message counts for a user, newest first
+func (r *ChatRepository) GetConversationsWithStatsForUser(userID int, page models.CursorParams) ([]models.ConversationWithStats, *int, error) {
+	tail, args, descending := cursorClause("c.id", page, []any{userID})
 	query := `
 		SELECT
-			c.id,
-			c.participant_one_id,
-			c.participant_two_id,
-			c.created_at,
-			COUNT(m.id) AS message_count
+			` + conversationColumns + `,
+			(SELECT COUNT(*) FROM messages m WHERE m.conversation_id = c.id) AS message_count,
+			other.id AS counterpart_id,
+			other.last_seen_at
 		FROM conversations c
-		LEFT JOIN messages m ON m.conversation_id = c.id
-		WHERE c.participant_one_id = $1 OR c.participant_two_id = $1
-		GROUP BY c.id
-		ORDER BY c.created_at DESC
-	`
+		LEFT JOIN LATERAL (
+			SELECT u.id, u.last_seen_at
+			FROM conversation_participants cp
+			JOIN users u ON u.id = cp.user_id
+			WHERE cp.conversation_id = c.id AND cp.user_id <> $1
+			LIMIT 1
+		) other ON NOT c.is_group
+		WHERE c.id IN (SELECT conversation_id FROM conversation_participants WHERE user_id = $1)
+	` + tail
 
-	rows, err := r.db.Query(query, userID)
+	rows, err := r.db.Query(query, args...)
 	if err != nil {
-		return nil, 0, fmt.Errorf("GetConversationsWithStatsForUser: %w", err)
+		return nil, nil, fmt.Errorf("GetConversationsWithStatsForUser: %w", err)
 	}
 	defer rows.Close()
 
-	var convs []models.ConversationWithStats
+	convs := make([]models.ConversationWithStats, 0)
 	for rows.Next() {
 		var c models.ConversationWithStats
-		if err := rows.Scan(&c.ID, &c.ParticipantOneID, &c.ParticipantTwoID, &c.CreatedAt, &c.MessageCount); err != nil {
-			return nil, 0, err
+		var createdBy, jobID, applicationID, counterpartID sql.NullInt64
+		var lastSeen sql.NullTime
+		if err := rows.Scan(&c.ID, &c.IsGroup, &c.Title, &createdBy, &jobID, &applicationID, &c.CreatedAt,
+			&c.MessageCount, &counterpartID, &lastSeen); err != nil {
+			return nil, nil, err
+		}
+		c.CreatedBy = nullIntPtr(createdBy)
+		c.JobID = nullIntPtr(jobID)
+		c.ApplicationID = nullIntPtr(applicationID)
+		if counterpartID.Valid {
+			c.Counterpart = &models.Presence{UserID: int(counterpartID.Int64)}
+			if lastSeen.Valid {
+				c.Counterpart.LastSeenAt = &lastSeen.Time
+			}
 		}
 		convs = append(convs, c)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	var nextCursor *int
+	if len(convs) > page.Limit {
+		convs = convs[:page.Limit]
+		next := convs[len(convs)-1].ID
+		nextCursor = &next
+	}
+	if !descending {
+		slices.Reverse(convs)
+	}
+
+	ptrs := make([]*models.Conversation, len(convs))
+	for i := range convs {
+		ptrs[i] = &convs[i].Conversation
+	}
+	if err := r.loadParticipants(ptrs); err != nil {
+		return nil, nil, err
+	}
+	return convs, nextCursor, nil
+}
+
+// inboxSnippetLength is the number of characters kept from the last message in the inbox
+const inboxSnippetLength = 140
+
+// inboxFilters maps the inbox filter names to conditions on the caller's participant row (me)
+var inboxFilters = map[string]string{
+	models.InboxFilterActive:   "me.archived_at IS NULL",
+	models.InboxFilterArchived: "me.archived_at IS NOT NULL",
+	models.InboxFilterPinned:   "me.pinned_at IS NOT NULL",
+	models.InboxFilterMuted:    "me.muted",
+	models.InboxFilterUnread: `EXISTS (
+		SELECT 1 FROM messages um
+		WHERE um.conversation_id = c.id AND um.sender_id <> $1
+			AND um.id > COALESCE(me.last_read_message_id, 0)
+	)`,
+	models.InboxFilterAll: "TRUE",
+}
+
+// GetInboxForUser returns the user's conversations matching the filter with the counterpart's profile,
+// the latest message, the unread count and the user's own settings. Pinned conversations come first,
+// then the rest by most recent activity.
+func (r *ChatRepository) GetInboxForUser(userID int, filter string) ([]models.InboxEntry, error) {
+	condition, ok := inboxFilters[filter]
+	if !ok {
+		condition = inboxFilters[models.InboxFilterActive]
+	}
+
+	query := `
+		SELECT
+			c.id,
+			c.is_group,
+			COALESCE(c.title, ''),
+			c.job_id,
+			c.application_id,
+			COALESCE(j.title, ''),
+			u.id,
+			u.role,
+			u.last_seen_at,
+			COALESCE(NULLIF(TRIM(CONCAT(js.first_name, ' ', js.last_name)), ''), ep.company_name, u.email) AS display_name,
+			COALESCE(js.logo_url, ep.logo_url, '') AS avatar_url,
+			lm.id,
+			lm.sender_id,
+			LEFT(lm.content, $2),
+			lm.created_at,
+			(
+				SELECT COUNT(*) FROM messages um
+				WHERE um.conversation_id = c.id AND um.sender_id <> $1
+					AND um.id > COALESCE(me.last_read_message_id, 0)
+			) AS unread_count,
+			COALESCE(lm.created_at, c.created_at) AS last_activity_at,
+			me.archived_at IS NOT NULL,
+			me.muted,
+			me.pinned_at IS NOT NULL
+		FROM conversations c
+		JOIN conversation_participants me ON me.conversation_id = c.id AND me.user_id = $1
+		LEFT JOIN LATERAL (
+			SELECT cp.user_id
+			FROM conversation_participants cp
+			WHERE cp.conversation_id = c.id AND cp.user_id <> $1
+			LIMIT 1
+		) other ON NOT c.is_group
+		LEFT JOIN users u ON u.id = other.user_id
+		LEFT JOIN job_seeker_profiles js ON js.user_id = u.id
+		LEFT JOIN employer_profiles ep ON ep.user_id = u.id
+		LEFT JOIN jobs j ON j.id = c.job_id
+		LEFT JOIN LATERAL (
+			SELECT m.id, m.sender_id, m.content, m.created_at
+			FROM messages m
+			WHERE m.conversation_id = c.id
+			ORDER BY m.id DESC
+			LIMIT 1
+		) lm ON TRUE
+		WHERE ` + condition + `
+		ORDER BY me.pinned_at IS NULL, last_activity_at DESC, c.id DESC
+	`
+
+	rows, err := r.db.Query(query, userID, inboxSnippetLength)
+	if err != nil {
+		return nil, fmt.Errorf("GetInboxForUser: %w", err)
+	}
+	defer rows.Close()
+
+	inbox := make([]models.InboxEntry, 0)
+	for rows.Next() {
+		var e models.InboxEntry
+		var lastSeen, sentAt sql.NullTime
+		var jobID, applicationID, counterpartID, lastID, lastSender sql.NullInt64
+		var role, displayName, avatarURL, snippet sql.NullString
+		err := rows.Scan(
+			&e.ConversationID,
+			&e.IsGroup,
+			&e.Title,
+			&jobID,
+			&applicationID,
+			&e.JobTitle,
+			&counterpartID,
+			&role,
+			&lastSeen,
+			&displayName,
+			&avatarURL,
+			&lastID,
+			&lastSender,
+			&snippet,
+			&sentAt,
+			&e.UnreadCount,
+			&e.LastActivityAt,
+			&e.Archived,
+			&e.Muted,
+			&e.Pinned,
+		)
+		if err != nil {
+			return nil, err
+		}
+		e.JobID = nullIntPtr(jobID)
+		e.ApplicationID = nullIntPtr(applicationID)
+		if counterpartID.Valid {
+			e.Counterpart = &models.InboxCounterpart{
+				Presence:    models.Presence{UserID: int(counterpartID.Int64)},
+				Role:        role.String,
+				DisplayName: displayName.String,
+				AvatarURL:   avatarURL.String,
+			}
+			if lastSeen.Valid {
+				e.Counterpart.LastSeenAt = &lastSeen.Time
+			}
+		}
+		if lastID.Valid {
+			e.LastMessage = &models.MessagePreview{
+				ID:       int(lastID.Int64),
+				SenderID: int(lastSender.Int64),
+				Snippet:  snippet.String,
+				SentAt:   sentAt.Time,
+			}
+		}
+		inbox = append(inbox, e)
+	}
+	return inbox, rows.Err()
+}
+
+// UpdateConversationSettings changes the user's own archive, mute and pin settings for a conversation.
+// Nil fields are left unchanged. It returns nil when the user is not a member of the conversation.
+func (r *ChatRepository) UpdateConversationSettings(conversationID, userID int, input models.ConversationSettingsInput) (*models.ConversationSettings, error) {
+	settings := models.ConversationSettings{ConversationID: conversationID}
+	var archivedAt, pinnedAt sql.NullTime
+	err := r.db.QueryRow(`
+		UPDATE conversation_participants SET
+			archived_at = CASE WHEN $3::BOOLEAN IS NULL THEN archived_at
+				WHEN $3 THEN COALESCE(archived_at, NOW()) END,
+			muted = COALESCE($4::BOOLEAN, muted),
+			pinned_at = CASE WHEN $5::BOOLEAN IS NULL THEN pinned_at
+				WHEN $5 THEN COALESCE(pinned_at, NOW()) END
+		WHERE conversation_id = $1 AND user_id = $2
+		RETURNING archived_at, muted, pinned_at
+	`, conversationID, userID, input.Archived, input.Muted, input.Pinned).Scan(&archivedAt, &settings.Muted, &pinnedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("UpdateConversationSettings: %w", err)
+	}
 
-	return convs, len(convs), nil
+	if archivedAt.Valid {
+		settings.Archived = true
+		settings.ArchivedAt = &archivedAt.Time
+	}
+	if pinnedAt.Valid {
+		settings.Pinned = true
+		settings.PinnedAt = &pinnedAt.Time
+	}
+	return &settings, nil
+}
+
+// searchHeadlineOptions controls the snippets returned by SearchMessages. Matches are wrapped in
+// control characters so the text can be HTML-escaped before they are turned into <mark> tags.
+const searchHeadlineOptions = "StartSel=\x02, StopSel=\x03, MaxWords=30, MinWords=10, MaxFragments=2, FragmentDelimiter=\" … \""
+
+var searchHighlighter = strings.NewReplacer("\x02", "<mark>", "\x03", "</mark>")
+
+// SearchMessages runs a full-text search over the messages of conversations the user belongs to.
+// Results are ordered by relevance, then newest first. Deleted messages are never matched.
+func (r *ChatRepository) SearchMessages(userID int, params models.MessageSearchParams) ([]models.MessageSearchResult, int, error) {
+	offset := (params.Page - 1) * params.Limit
+
+	const matches = `
+		FROM messages m
+		JOIN conversation_participants cp ON cp.conversation_id = m.conversation_id AND cp.user_id = $1
+		WHERE m.deleted_at IS NULL
+			AND to_tsvector('english', m.content) @@ websearch_to_tsquery('english', $2)
+			AND ($3 = 0 OR m.conversation_id = $3)
+	`
+
+	var total int
+	err := r.db.QueryRow(`SELECT COUNT(*) `+matches, userID, params.Query, params.ConversationID).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("SearchMessages: %w", err)
+	}
+
+	rows, err := r.db.Query(`
+		SELECT m.id, m.conversation_id, m.sender_id,
+			ts_headline('english', m.content, websearch_to_tsquery('english', $2), $4),
+			m.created_at
+		`+matches+`
+		ORDER BY ts_rank(to_tsvector('english', m.content), websearch_to_tsquery('english', $2)) DESC, m.id DESC
+		LIMIT $5 OFFSET $6
+	`, userID, params.Query, params.ConversationID, searchHeadlineOptions, params.Limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("SearchMessages: %w", err)
+	}
+	defer rows.Close()
+
+	results := make([]models.MessageSearchResult, 0)
+	for rows.Next() {
+		var res models.MessageSearchResult
+		if err := rows.Scan(&res.MessageID, &res.ConversationID, &res.SenderID, &res.Snippet, &res.SentAt); err != nil {
+			return nil, 0, err
+		}
+		res.Snippet = searchHighlighter.Replace(html.EscapeString(res.Snippet))
+		results = append(results, res)
+	}
+	return results, total, rows.Err()
 }
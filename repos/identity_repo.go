@@ -0,0 +1,213 @@
+package repos
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrIdentityNotFound  = errors.New("no user is linked to this identity")
+	ErrIdentityInUse     = errors.New("identity is already linked to a user")
+	ErrLoginStateInvalid = errors.New("login state is invalid or expired")
+	ErrLinkRequiresLogin = errors.New("account must be linked after logging in with its password")
+	ErrEmailNotVerified  = errors.New("email address is not verified")
+)
+
+// OIDCLoginState is what is remembered about a login while the user is at the provider
+type OIDCLoginState struct {
+	CodeVerifier string
+	Nonce        string
+	Role         string
+	// LinkUserID is the logged-in user to link the provider account to, or 0 for a login
+	LinkUserID int
+}
+
+// IdentityRepository links users to their accounts at OpenID Connect providers
+type IdentityRepository struct {
+	db *sql.DB
+}
+
+func NewIdentityRepository(db *sql.DB) *IdentityRepository {
+	return &IdentityRepository{db: db}
+}
+
+// SaveLoginState stores a login that was sent to the provider, removing expired ones
+func (r *IdentityRepository) SaveLoginState(stateHash, provider string, state OIDCLoginState, expiresAt time.Time) error {
+	if _, err := r.db.Exec(`DELETE FROM oidc_login_states WHERE expires_at <= NOW()`); err != nil {
+		return fmt.Errorf("SaveLoginState: %w", err)
+	}
+	_, err := r.db.Exec(`
+		INSERT INTO oidc_login_states (state_hash, provider, code_verifier, nonce, role, link_user_id, expires_at)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, 0), $7)
+	`, stateHash, provider, state.CodeVerifier, state.Nonce, state.Role, state.LinkUserID, expiresAt)
+	if err != nil {
+		return fmt.Errorf("SaveLoginState: %w", err)
+	}
+	return nil
+}
+
+// ConsumeLoginState removes and returns the login the provider sent the user back for, so a
+// callback cannot be replayed. It returns ErrLoginStateInvalid for unknown or expired states.
+func (r *IdentityRepository) ConsumeLoginState(stateHash, provider string) (*OIDCLoginState, error) {
+	var state OIDCLoginState
+	var expiresAt time.Time
+	err := r.db.QueryRow(`
+		DELETE FROM oidc_login_states WHERE state_hash = $1 AND provider = $2
+		RETURNING code_verifier, nonce, role, COALESCE(link_user_id, 0), expires_at
+	`, stateHash, provider).Scan(&state.CodeVerifier, &state.Nonce, &state.Role, &state.LinkUserID, &expiresAt)
+	if err == sql.ErrNoRows || (err == nil && time.Now().After(expiresAt)) {
+		return nil, ErrLoginStateInvalid
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ConsumeLoginState: %w", err)
+	}
+	return &state, nil
+}
+
+// FindUser returns the user linked to the provider account and records the login.
+// It returns ErrIdentityNotFound when the account is not linked yet.
+func (r *IdentityRepository) FindUser(provider, subject string) (int, error) {
+	var userID int
+	err := r.db.QueryRow(`
+		UPDATE user_identities SET last_login_at = NOW()
+		WHERE provider = $1 AND subject = $2
+		RETURNING user_id
+	`, provider, subject).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return 0, ErrIdentityNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("FindUser: %w", err)
+	}
+	return userID, nil
+}
+
+// Link connects a provider account to a user who is logged in, which proves they own the account.
+// Only users who verified their email can link, since an unverified account may belong to someone
+// squatting the address. It returns ErrEmailNotVerified for them, and ErrIdentityInUse when the
+// provider account is linked to another user.
+func (r *IdentityRepository) Link(userID int, provider, subject, email string) error {
+	res, err := r.db.Exec(`
+		INSERT INTO user_identities (user_id, provider, subject, email)
+		SELECT id, $2, $3, $4 FROM users WHERE id = $1 AND email_verified_at IS NOT NULL
+	`, userID, provider, subject, email)
+	if err != nil {
+		return identityError("Link", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("Link: %w", err)
+	}
+	if n == 0 {
+		return ErrEmailNotVerified
+	}
+	return nil
+}
+
+// LinkByEmail connects a provider account to the existing user with the email the provider verified.
+//
+// If the user had not verified the address themselves, whoever registered it may not own it, so
+// the password, two-factor setup, sessions, provider accounts and pending email change they set
+// up are removed and the address is marked verified. The owner can set a password again with a
+// password reset. Accounts that predate email verification are not linked this way, since their
+// owners may never have proven the address but cannot be told apart from squatters; it returns
+// ErrLinkRequiresLogin for them.
+func (r *IdentityRepository) LinkByEmail(userID int, provider, subject, email string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("LinkByEmail: %w", err)
+	}
+	defer tx.Rollback()
+
+	var verified, backfilled bool
+	err = tx.QueryRow(`
+		SELECT email_verified_at IS NOT NULL, email_verification_backfilled
+		FROM users WHERE id = $1
+		FOR UPDATE
+	`, userID).Scan(&verified, &backfilled)
+	if err != nil {
+		return fmt.Errorf("LinkByEmail: %w", err)
+	}
+	if backfilled {
+		return ErrLinkRequiresLogin
+	}
+
+	if !verified {
+		statements := []string{
+			`UPDATE users SET password_hash = '', pending_email = NULL, email_verified_at = NOW(), updated_at = NOW() WHERE id = $1`,
+			`UPDATE auth_sessions SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL`,
+			`DELETE FROM user_totp WHERE user_id = $1`,
+			`DELETE FROM totp_recovery_codes WHERE user_id = $1`,
+			`DELETE FROM user_identities WHERE user_id = $1`,
+		}
+		for _, statement := range statements {
+			if _, err := tx.Exec(statement, userID); err != nil {
+				return fmt.Errorf("LinkByEmail: %w", err)
+			}
+		}
+	}
+
+	if err := insertIdentity(tx, userID, provider, subject, email); err != nil {
+		return identityError("LinkByEmail", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("LinkByEmail: %w", err)
+	}
+	return nil
+}
+
+// CreateUser creates a user for a provider account and links the two. The email is already
+// verified by the provider. The user has no password until they set one with a password reset.
+func (r *IdentityRepository) CreateUser(email, role, provider, subject string) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("CreateUser: %w", err)
+	}
+	defer tx.Rollback()
+
+	var userID int
+	err = tx.QueryRow(`
+		INSERT INTO users (email, password_hash, role, email_verified_at, created_at, updated_at)
+		VALUES ($1, '', $2, NOW(), NOW(), NOW())
+		RETURNING id
+	`, email, role).Scan(&userID)
+	if err != nil {
+		return 0, fmt.Errorf("CreateUser: %w", err)
+	}
+
+	if err := insertIdentity(tx, userID, provider, subject, email); err != nil {
+		return 0, identityError("CreateUser", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("CreateUser: %w", err)
+	}
+	return userID, nil
+}
+
+// execer is a *sql.DB or *sql.Tx
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+func insertIdentity(db execer, userID int, provider, subject, email string) error {
+	_, err := db.Exec(`
+		INSERT INTO user_identities (user_id, provider, subject, email)
+		VALUES ($1, $2, $3, $4)
+	`, userID, provider, subject, email)
+	return err
+}
+
+// identityError maps unique violations on the provider account to ErrIdentityInUse
+func identityError(op string, err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == "user_identities_provider_subject_key" {
+		return ErrIdentityInUse
+	}
+	return fmt.Errorf("%s: %w", op, err)
+}
@@ -0,0 +1,89 @@
+package repos
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+)
+
+// testDB connects to the migrated database in TEST_DATABASE_URL, skipping the test without one
+func testDB(t *testing.T) *sql.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Ping(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// TestLinkByEmailRemovesSquatterIdentities covers an account registered by someone who does not
+// own the address and who tries to keep a way in once the owner logs in with a provider
+func TestLinkByEmailRemovesSquatterIdentities(t *testing.T) {
+	db := testDB(t)
+	repo := NewIdentityRepository(db)
+
+	email := fmt.Sprintf("victim-%d@corp.example", time.Now().UnixNano())
+	var userID int
+	err := db.QueryRow(`
+		INSERT INTO users (email, password_hash, role, pending_email, created_at, updated_at)
+		VALUES ($1, 'squatter-hash', 'employer', 'squatter@evil.example', NOW(), NOW())
+		RETURNING id
+	`, email).Scan(&userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Exec(`DELETE FROM users WHERE id = $1`, userID) })
+
+	// The squatter cannot attach their own provider account while the address is unverified
+	if err := repo.Link(userID, "mock", "squatter", "squatter@evil.example"); !errors.Is(err, ErrEmailNotVerified) {
+		t.Fatalf("Link on an unverified account = %v, want ErrEmailNotVerified", err)
+	}
+
+	// Nor does one that got attached some other way survive the owner's takeover
+	if _, err := db.Exec(`
+		INSERT INTO user_identities (user_id, provider, subject, email) VALUES ($1, 'mock', 'squatter', 'squatter@evil.example')
+	`, userID); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.LinkByEmail(userID, "mock", "owner", email); err != nil {
+		t.Fatalf("LinkByEmail: %v", err)
+	}
+
+	if _, err := repo.FindUser("mock", "squatter"); !errors.Is(err, ErrIdentityNotFound) {
+		t.Errorf("squatter identity after takeover: FindUser = %v, want ErrIdentityNotFound", err)
+	}
+	if got, err := repo.FindUser("mock", "owner"); err != nil || got != userID {
+		t.Errorf("owner identity: FindUser = %d, %v; want %d", got, err, userID)
+	}
+
+	var passwordHash, pendingEmail string
+	var verified bool
+	err = db.QueryRow(`
+		SELECT password_hash, COALESCE(pending_email, ''), email_verified_at IS NOT NULL FROM users WHERE id = $1
+	`, userID).Scan(&passwordHash, &pendingEmail, &verified)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if passwordHash != "" || pendingEmail != "" || !verified {
+		t.Errorf("after takeover: password_hash = %q, pending_email = %q, verified = %v; want empty, empty and verified",
+			passwordHash, pendingEmail, verified)
+	}
+
+	// Once verified, the owner can link further provider accounts
+	if err := repo.Link(userID, "mock", "owner-2", email); err != nil {
+		t.Errorf("Link on a verified account: %v", err)
+	}
+}
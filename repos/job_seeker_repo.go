@@ -24,10 +24,10 @@ func (r *JobSeekerRepository) Create(userID int, profile models.JobSeekerProfile
 	query := `
 	INSERT INTO job_seeker_profiles (
 		user_id, first_name, last_name, headline, summary, phone, location, 
-		resume_url, logo_url, skills, experience_level, created_at, updated_at
+		resume_url, logo_url, skills, experience_level, discoverable, created_at, updated_at
 	) VALUES (
 		$1, $2, $3, $4, $5, $6, $7,
-		$8, $9, $10, $11, $12, $13
+		$8, $9, $10, $11, $12, $13, $14
 	)
 	RETURNING id
 	`
@@ -46,6 +46,7 @@ func (r *JobSeekerRepository) Create(userID int, profile models.JobSeekerProfile
 		profile.LogoUrl,
 		pq.Array(profile.Skills),
 		profile.ExperienceLevel,
+		profile.Discoverable,
 		now,
 		now,
 	).Scan(&id)
@@ -59,7 +60,7 @@ func (r *JobSeekerRepository) Create(userID int, profile models.JobSeekerProfile
 // GetByUserID retrieves a job seeker profile by user ID
 func (r *JobSeekerRepository) GetByUserID(userID int) (*models.JobSeekerProfile, error) {
 	query := `
-		SELECT id, user_id, first_name, last_name, headline, summary, phone, location, resume_url,logo_url,skills,experience_level, created_at, updated_at
+		SELECT id, user_id, first_name, last_name, headline, summary, phone, location, resume_url,logo_url,skills,experience_level, discoverable, created_at, updated_at
 		FROM job_seeker_profiles
 		WHERE user_id = $1
 	`
@@ -78,6 +79,7 @@ func (r *JobSeekerRepository) GetByUserID(userID int) (*models.JobSeekerProfile,
 		&profile.LogoUrl,
 		pq.Array(&profile.Skills),
 		&profile.ExperienceLevel,
+		&profile.Discoverable,
 		&profile.CreatedAt,
 		&profile.UpdatedAt,
 	)
@@ -94,7 +96,7 @@ func (r *JobSeekerRepository) GetByUserID(userID int) (*models.JobSeekerProfile,
 // GetByID retrieves a job seeker profile by ID
 func (r *JobSeekerRepository) GetByID(id int) (*models.JobSeekerProfile, error) {
 	query := `
-		SELECT id, user_id, first_name, last_name, headline, summary, phone, location, resume_url,logo_url, skills,experience_level, created_at, updated_at
+		SELECT id, user_id, first_name, last_name, headline, summary, phone, location, resume_url,logo_url, skills,experience_level, discoverable, created_at, updated_at
 		FROM job_seeker_profiles
 		WHERE id = $1
 	`
@@ -113,6 +115,7 @@ func (r *JobSeekerRepository) GetByID(id int) (*models.JobSeekerProfile, error)
 		&profile.LogoUrl,
 		pq.Array(&profile.Skills),
 		&profile.ExperienceLevel, // <-- add this
+		&profile.Discoverable,
 		&profile.CreatedAt,
 		&profile.UpdatedAt,
 	)
@@ -131,8 +134,8 @@ func (r *JobSeekerRepository) Update(id int, input models.JobSeekerProfileInput)
 	UPDATE job_seeker_profiles
 	SET first_name = $1, last_name = $2, phone = $3, location = $4,
 		headline = $5, summary = $6, resume_url = $7, logo_url = $8,
-		skills = $9, experience_level = $10, updated_at = NOW()
-	WHERE id = $11
+		skills = $9, experience_level = $10, discoverable = $11, updated_at = NOW()
+	WHERE id = $12
 `
 	_, err := r.db.Exec(query,
 		input.FirstName,
@@ -145,6 +148,7 @@ func (r *JobSeekerRepository) Update(id int, input models.JobSeekerProfileInput)
 		input.LogoUrl,
 		pq.Array(input.Skills),
 		input.ExperienceLevel,
+		input.Discoverable,
 		id,
 	)
 	return err
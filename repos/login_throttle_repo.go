@@ -0,0 +1,106 @@
+package repos
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	// loginFailureWindow is how long failed attempts are remembered after the last one
+	loginFailureWindow = 24 * time.Hour
+	// maxLoginLockout caps the exponential backoff
+	maxLoginLockout = 24 * time.Hour
+)
+
+// LoginThrottleRepository counts failed logins per account and per IP and locks them out
+type LoginThrottleRepository struct {
+	db *sql.DB
+}
+
+func NewLoginThrottleRepository(db *sql.DB) *LoginThrottleRepository {
+	return &LoginThrottleRepository{db: db}
+}
+
+// EmailKey is the key failed logins for an account are counted under
+func EmailKey(email string) string {
+	return "email:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+// IPKey is the key failed logins from a client IP are counted under
+func IPKey(ip string) string {
+	return "ip:" + ip
+}
+
+// LockedUntil returns the latest lockout among the keys, or the zero time when none is locked
+func (r *LoginThrottleRepository) LockedUntil(keys ...string) (time.Time, error) {
+	var lockedUntil sql.NullTime
+	err := r.db.QueryRow(`
+		SELECT MAX(locked_until) FROM login_throttles
+		WHERE key = ANY($1) AND locked_until > NOW()
+	`, pq.Array(keys)).Scan(&lockedUntil)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("LockedUntil: %w", err)
+	}
+	return lockedUntil.Time, nil
+}
+
+// RecordFailure counts a failed attempt under the key. From maxAttempts failures on, the key is
+// locked for lockout, doubled for every further failure. It returns the new lockout end, or the zero
+// time when the key is not locked.
+func (r *LoginThrottleRepository) RecordFailure(key string, maxAttempts int, lockout time.Duration) (time.Time, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("RecordFailure: %w", err)
+	}
+	defer tx.Rollback()
+
+	var failures int
+	err = tx.QueryRow(`
+		INSERT INTO login_throttles (key, failures, last_failed_at)
+		VALUES ($1, 1, NOW())
+		ON CONFLICT (key) DO UPDATE SET
+			failures = CASE
+				WHEN login_throttles.last_failed_at < NOW() - $2 * INTERVAL '1 second' THEN 1
+				ELSE login_throttles.failures + 1
+			END,
+			last_failed_at = NOW()
+		RETURNING failures
+	`, key, loginFailureWindow.Seconds()).Scan(&failures)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("RecordFailure: %w", err)
+	}
+
+	var lockedUntil time.Time
+	if failures >= maxAttempts {
+		lockedUntil = time.Now().Add(lockoutFor(failures-maxAttempts, lockout))
+		if _, err := tx.Exec(`UPDATE login_throttles SET locked_until = $2 WHERE key = $1`, key, lockedUntil); err != nil {
+			return time.Time{}, fmt.Errorf("RecordFailure: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return time.Time{}, fmt.Errorf("RecordFailure: %w", err)
+	}
+	return lockedUntil, nil
+}
+
+// lockoutFor doubles the base lockout for each failure past the threshold, up to maxLoginLockout
+func lockoutFor(extraFailures int, base time.Duration) time.Duration {
+	d := base
+	for i := 0; i < extraFailures && d < maxLoginLockout; i++ {
+		d *= 2
+	}
+	return min(d, maxLoginLockout)
+}
+
+// Reset forgets the failed attempts under the key and lifts its lockout
+func (r *LoginThrottleRepository) Reset(key string) error {
+	if _, err := r.db.Exec(`DELETE FROM login_throttles WHERE key = $1`, key); err != nil {
+		return fmt.Errorf("Reset: %w", err)
+	}
+	return nil
+}
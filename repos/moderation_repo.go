@@ -0,0 +1,202 @@
+package repos
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/XORbit01/jobseeker-backend/models"
+)
+
+var (
+	ErrUserNotFound   = errors.New("user not found")
+	ErrReportNotFound = errors.New("report not found")
+)
+
+type ModerationRepository struct {
+	db *sql.DB
+}
+
+func NewModerationRepository(db *sql.DB) *ModerationRepository {
+	return &ModerationRepository{db: db}
+}
+
+// userExists reports whether a user with the given ID exists
+func (r *ModerationRepository) userExists(userID int) (bool, error) {
+	var exists bool
+	err := r.db.QueryRow(`SELECT EXISTS(SELECT 1 FROM users WHERE id = $1)`, userID).Scan(&exists)
+	return exists, err
+}
+
+// BlockUser adds blockedID to the block list of blockerID. Blocking twice is a no-op.
+func (r *ModerationRepository) BlockUser(blockerID, blockedID int) error {
+	exists, err := r.userExists(blockedID)
+	if err != nil {
+		return fmt.Errorf("BlockUser: %w", err)
+	}
+	if !exists {
+		return ErrUserNotFound
+	}
+
+	_, err = r.db.Exec(`
+		INSERT INTO user_blocks (blocker_id, blocked_id)
+		VALUES ($1, $2)
+		ON CONFLICT (blocker_id, blocked_id) DO NOTHING
+	`, blockerID, blockedID)
+	if err != nil {
+		return fmt.Errorf("BlockUser: %w", err)
+	}
+	return nil
+}
+
+// UnblockUser removes blockedID from the block list of blockerID
+func (r *ModerationRepository) UnblockUser(blockerID, blockedID int) error {
+	_, err := r.db.Exec(`
+		DELETE FROM user_blocks WHERE blocker_id = $1 AND blocked_id = $2
+	`, blockerID, blockedID)
+	if err != nil {
+		return fmt.Errorf("UnblockUser: %w", err)
+	}
+	return nil
+}
+
+// GetBlockedUsers lists the users blocked by the given user, most recent first
+func (r *ModerationRepository) GetBlockedUsers(blockerID int) ([]models.UserBlock, error) {
+	rows, err := r.db.Query(`
+		SELECT blocked_id, created_at
+		FROM user_blocks
+		WHERE blocker_id = $1
+		ORDER BY created_at DESC
+	`, blockerID)
+	if err != nil {
+		return nil, fmt.Errorf("GetBlockedUsers: %w", err)
+	}
+	defer rows.Close()
+
+	blocks := make([]models.UserBlock, 0)
+	for rows.Next() {
+		var b models.UserBlock
+		if err := rows.Scan(&b.UserID, &b.CreatedAt); err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, b)
+	}
+	return blocks, rows.Err()
+}
+
+// CreateReport stores an abuse report and returns its ID
+func (r *ModerationRepository) CreateReport(reporterID int, input models.ReportInput) (int, error) {
+	exists, err := r.userExists(input.ReportedUserID)
+	if err != nil {
+		return 0, fmt.Errorf("CreateReport: %w", err)
+	}
+	if !exists {
+		return 0, ErrUserNotFound
+	}
+
+	var id int
+	err = r.db.QueryRow(`
+		INSERT INTO message_reports (reporter_id, reported_user_id, conversation_id, message_id, reason, details)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''))
+		RETURNING id
+	`, reporterID, input.ReportedUserID, input.ConversationID, input.MessageID, input.Reason, input.Details).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("CreateReport: %w", err)
+	}
+	return id, nil
+}
+
+// reportColumns is the column list read by scanReport
+const reportColumns = `id, reporter_id, reported_user_id, conversation_id, message_id, reason,
+	COALESCE(details, ''), status, reviewed_by, reviewed_at, created_at`
+
+// scanReport reads a row selected with reportColumns
+func scanReport(row rowScanner) (models.MessageReport, error) {
+	var rep models.MessageReport
+	var conversationID, messageID, reviewedBy sql.NullInt64
+	var reviewedAt sql.NullTime
+	err := row.Scan(&rep.ID, &rep.ReporterID, &rep.ReportedUserID, &conversationID, &messageID, &rep.Reason,
+		&rep.Details, &rep.Status, &reviewedBy, &reviewedAt, &rep.CreatedAt)
+	if err != nil {
+		return rep, err
+	}
+	if conversationID.Valid {
+		id := int(conversationID.Int64)
+		rep.ConversationID = &id
+	}
+	if messageID.Valid {
+		id := int(messageID.Int64)
+		rep.MessageID = &id
+	}
+	if reviewedBy.Valid {
+		id := int(reviewedBy.Int64)
+		rep.ReviewedBy = &id
+	}
+	if reviewedAt.Valid {
+		rep.ReviewedAt = &reviewedAt.Time
+	}
+	return rep, nil
+}
+
+// GetReportByID retrieves a single report
+func (r *ModerationRepository) GetReportByID(id int) (*models.MessageReport, error) {
+	rep, err := scanReport(r.db.QueryRow(`SELECT `+reportColumns+` FROM message_reports WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrReportNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("GetReportByID: %w", err)
+	}
+	return &rep, nil
+}
+
+// GetReports lists reports, newest first, optionally filtered by status
+func (r *ModerationRepository) GetReports(status string, page, limit int) ([]models.MessageReport, int, error) {
+	offset := (page - 1) * limit
+
+	var total int
+	err := r.db.QueryRow(`
+		SELECT COUNT(*) FROM message_reports WHERE ($1 = '' OR status = $1)
+	`, status).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("GetReports: %w", err)
+	}
+
+	rows, err := r.db.Query(`
+		SELECT `+reportColumns+`
+		FROM message_reports
+		WHERE ($1 = '' OR status = $1)
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2 OFFSET $3
+	`, status, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("GetReports: %w", err)
+	}
+	defer rows.Close()
+
+	reports := make([]models.MessageReport, 0)
+	for rows.Next() {
+		rep, err := scanReport(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		reports = append(reports, rep)
+	}
+	return reports, total, rows.Err()
+}
+
+// UpdateReportStatus records a moderator's decision on a report
+func (r *ModerationRepository) UpdateReportStatus(id, reviewerID int, status string) error {
+	res, err := r.db.Exec(`
+		UPDATE message_reports
+		SET status = $1, reviewed_by = $2, reviewed_at = NOW()
+		WHERE id = $3
+	`, status, reviewerID, id)
+	if err != nil {
+		return fmt.Errorf("UpdateReportStatus: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrReportNotFound
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package repos
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrRefreshTokenInvalid = errors.New("refresh token is invalid or expired")
+	ErrRefreshTokenReused  = errors.New("refresh token was already used")
+)
+
+// SessionRepository stores login sessions and the refresh tokens that keep them alive
+type SessionRepository struct {
+	db *sql.DB
+}
+
+func NewSessionRepository(db *sql.DB) *SessionRepository {
+	return &SessionRepository{db: db}
+}
+
+// Create starts a session for the user with its first refresh token and returns the session ID.
+// Sessions of the user whose refresh tokens have all expired are removed along the way.
+func (r *SessionRepository) Create(userID int, tokenHash string, expiresAt time.Time, userAgent, ipAddress string) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("Create: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		DELETE FROM auth_sessions s
+		WHERE s.user_id = $1
+		  AND NOT EXISTS (
+			SELECT 1 FROM refresh_tokens t
+			WHERE t.session_id = s.id AND t.expires_at > NOW()
+		  )
+	`, userID)
+	if err != nil {
+		return 0, fmt.Errorf("Create: %w", err)
+	}
+
+	var sessionID int
+	err = tx.QueryRow(`
+		INSERT INTO auth_sessions (user_id, user_agent, ip_address)
+		VALUES ($1, NULLIF($2, ''), NULLIF($3, ''))
+		RETURNING id
+	`, userID, userAgent, ipAddress).Scan(&sessionID)
+	if err != nil {
+		return 0, fmt.Errorf("Create: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO refresh_tokens (session_id, token_hash, expires_at)
+		VALUES ($1, $2, $3)
+	`, sessionID, tokenHash, expiresAt)
+	if err != nil {
+		return 0, fmt.Errorf("Create: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("Create: %w", err)
+	}
+	return sessionID, nil
+}
+
+// Rotate exchanges a refresh token for a new one in the same session and returns the session's
+// user and ID. Presenting a token that was already exchanged means it has leaked, so the whole
+// session is revoked and ErrRefreshTokenReused is returned.
+func (r *SessionRepository) Rotate(oldHash, newHash string, expiresAt time.Time) (userID, sessionID int, err error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, 0, fmt.Errorf("Rotate: %w", err)
+	}
+	defer tx.Rollback()
+
+	var tokenID int
+	var tokenExpiresAt time.Time
+	var usedAt, revokedAt sql.NullTime
+	err = tx.QueryRow(`
+		SELECT t.id, t.session_id, t.expires_at, t.used_at, s.user_id, s.revoked_at
+		FROM refresh_tokens t
+		JOIN auth_sessions s ON s.id = t.session_id
+		WHERE t.token_hash = $1
+		FOR UPDATE OF t, s
+	`, oldHash).Scan(&tokenID, &sessionID, &tokenExpiresAt, &usedAt, &userID, &revokedAt)
+	if err == sql.ErrNoRows {
+		return 0, 0, ErrRefreshTokenInvalid
+	}
+	if err != nil {
+		return 0, 0, fmt.Errorf("Rotate: %w", err)
+	}
+
+	if revokedAt.Valid {
+		return 0, 0, ErrRefreshTokenInvalid
+	}
+	if usedAt.Valid {
+		if _, err := tx.Exec(`UPDATE auth_sessions SET revoked_at = NOW() WHERE id = $1`, sessionID); err != nil {
+			return 0, 0, fmt.Errorf("Rotate: %w", err)
+		}
+		if err := tx.Commit(); err != nil {
+			return 0, 0, fmt.Errorf("Rotate: %w", err)
+		}
+		return 0, 0, ErrRefreshTokenReused
+	}
+	if !tokenExpiresAt.After(time.Now()) {
+		return 0, 0, ErrRefreshTokenInvalid
+	}
+
+	if _, err := tx.Exec(`UPDATE refresh_tokens SET used_at = NOW() WHERE id = $1`, tokenID); err != nil {
+		return 0, 0, fmt.Errorf("Rotate: %w", err)
+	}
+	_, err = tx.Exec(`
+		INSERT INTO refresh_tokens (session_id, token_hash, expires_at)
+		VALUES ($1, $2, $3)
+	`, sessionID, newHash, expiresAt)
+	if err != nil {
+		return 0, 0, fmt.Errorf("Rotate: %w", err)
+	}
+	if _, err := tx.Exec(`UPDATE auth_sessions SET last_used_at = NOW() WHERE id = $1`, sessionID); err != nil {
+		return 0, 0, fmt.Errorf("Rotate: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, fmt.Errorf("Rotate: %w", err)
+	}
+	return userID, sessionID, nil
+}
+
+// IsActive reports whether the user's session exists and has not been revoked
+func (r *SessionRepository) IsActive(sessionID, userID int) (bool, error) {
+	var active bool
+	err := r.db.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1 FROM auth_sessions
+			WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+		)
+	`, sessionID, userID).Scan(&active)
+	if err != nil {
+		return false, fmt.Errorf("IsActive: %w", err)
+	}
+	return active, nil
+}
+
+// Revoke ends one of the user's sessions. Revoking a session twice is not an error.
+func (r *SessionRepository) Revoke(sessionID, userID int) error {
+	_, err := r.db.Exec(`
+		UPDATE auth_sessions SET revoked_at = NOW()
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+	`, sessionID, userID)
+	if err != nil {
+		return fmt.Errorf("Revoke: %w", err)
+	}
+	return nil
+}
+
+// RevokeAll ends every active session of the user and returns how many were revoked
+func (r *SessionRepository) RevokeAll(userID int) (int, error) {
+	res, err := r.db.Exec(`
+		UPDATE auth_sessions SET revoked_at = NOW()
+		WHERE user_id = $1 AND revoked_at IS NULL
+	`, userID)
+	if err != nil {
+		return 0, fmt.Errorf("RevokeAll: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("RevokeAll: %w", err)
+	}
+	return int(n), nil
+}
@@ -0,0 +1,153 @@
+package repos
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/XORbit01/jobseeker-backend/models"
+	"github.com/lib/pq"
+)
+
+var (
+	ErrTemplateNotFound      = errors.New("template not found")
+	ErrDuplicateTemplateName = errors.New("a template with this name already exists")
+)
+
+type TemplateRepository struct {
+	db *sql.DB
+}
+
+func NewTemplateRepository(db *sql.DB) *TemplateRepository {
+	return &TemplateRepository{db: db}
+}
+
+// templateError maps unique violations on the template name to ErrDuplicateTemplateName
+func templateError(op string, err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return ErrDuplicateTemplateName
+	}
+	return fmt.Errorf("%s: %w", op, err)
+}
+
+// Create stores a new template for the employer
+func (r *TemplateRepository) Create(employerUserID int, input models.MessageTemplateInput) (*models.MessageTemplate, error) {
+	t := models.MessageTemplate{Name: input.Name, Body: input.Body}
+	err := r.db.QueryRow(`
+		INSERT INTO message_templates (employer_user_id, name, body)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at, updated_at
+	`, employerUserID, input.Name, input.Body).Scan(&t.ID, &t.CreatedAt, &t.UpdatedAt)
+	if err != nil {
+		return nil, templateError("Create", err)
+	}
+	return &t, nil
+}
+
+// GetByEmployer lists the employer's templates by name
+func (r *TemplateRepository) GetByEmployer(employerUserID int) ([]models.MessageTemplate, error) {
+	rows, err := r.db.Query(`
+		SELECT id, name, body, created_at, updated_at
+		FROM message_templates
+		WHERE employer_user_id = $1
+		ORDER BY name
+	`, employerUserID)
+	if err != nil {
+		return nil, fmt.Errorf("GetByEmployer: %w", err)
+	}
+	defer rows.Close()
+
+	templates := make([]models.MessageTemplate, 0)
+	for rows.Next() {
+		var t models.MessageTemplate
+		if err := rows.Scan(&t.ID, &t.Name, &t.Body, &t.CreatedAt, &t.UpdatedAt); err != nil {
+			return nil, err
+		}
+		templates = append(templates, t)
+	}
+	return templates, rows.Err()
+}
+
+// GetByID retrieves one of the employer's templates
+func (r *TemplateRepository) GetByID(id, employerUserID int) (*models.MessageTemplate, error) {
+	var t models.MessageTemplate
+	err := r.db.QueryRow(`
+		SELECT id, name, body, created_at, updated_at
+		FROM message_templates
+		WHERE id = $1 AND employer_user_id = $2
+	`, id, employerUserID).Scan(&t.ID, &t.Name, &t.Body, &t.CreatedAt, &t.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrTemplateNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("GetByID: %w", err)
+	}
+	return &t, nil
+}
+
+// Update replaces the name and body of one of the employer's templates
+func (r *TemplateRepository) Update(id, employerUserID int, input models.MessageTemplateInput) (*models.MessageTemplate, error) {
+	t := models.MessageTemplate{ID: id, Name: input.Name, Body: input.Body}
+	err := r.db.QueryRow(`
+		UPDATE message_templates
+		SET name = $1, body = $2, updated_at = NOW()
+		WHERE id = $3 AND employer_user_id = $4
+		RETURNING created_at, updated_at
+	`, input.Name, input.Body, id, employerUserID).Scan(&t.CreatedAt, &t.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrTemplateNotFound
+	}
+	if err != nil {
+		return nil, templateError("Update", err)
+	}
+	return &t, nil
+}
+
+// Delete removes one of the employer's templates
+func (r *TemplateRepository) Delete(id, employerUserID int) error {
+	res, err := r.db.Exec(`DELETE FROM message_templates WHERE id = $1 AND employer_user_id = $2`, id, employerUserID)
+	if err != nil {
+		return fmt.Errorf("Delete: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrTemplateNotFound
+	}
+	return nil
+}
+
+// GetCandidateFirstName returns the first name on a job seeker's profile, or "" when there is none
+func (r *TemplateRepository) GetCandidateFirstName(userID int) (string, error) {
+	var name string
+	err := r.db.QueryRow(`SELECT first_name FROM job_seeker_profiles WHERE user_id = $1`, userID).Scan(&name)
+	if err != nil && err != sql.ErrNoRows {
+		return "", fmt.Errorf("GetCandidateFirstName: %w", err)
+	}
+	return name, nil
+}
+
+// GetCompanyName returns the company name on an employer's profile, or "" when there is none
+func (r *TemplateRepository) GetCompanyName(employerUserID int) (string, error) {
+	var name string
+	err := r.db.QueryRow(`SELECT company_name FROM employer_profiles WHERE user_id = $1`, employerUserID).Scan(&name)
+	if err != nil && err != sql.ErrNoRows {
+		return "", fmt.Errorf("GetCompanyName: %w", err)
+	}
+	return name, nil
+}
+
+// GetJobTitle returns the title of a job, or "" when it does not exist. When employerUserID is
+// not zero the job must also have been posted by that employer.
+func (r *TemplateRepository) GetJobTitle(jobID, employerUserID int) (string, error) {
+	var title string
+	err := r.db.QueryRow(`
+		SELECT j.title
+		FROM jobs j
+		JOIN employer_profiles e ON e.id = j.employer_id
+		WHERE j.id = $1 AND ($2 = 0 OR e.user_id = $2)
+	`, jobID, employerUserID).Scan(&title)
+	if err != nil && err != sql.ErrNoRows {
+		return "", fmt.Errorf("GetJobTitle: %w", err)
+	}
+	return title, nil
+}
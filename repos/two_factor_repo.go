@@ -0,0 +1,213 @@
+package repos
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrTwoFactorEnabled    = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotStarted = errors.New("two-factor setup has not been started")
+	ErrChallengeInvalid    = errors.New("two-factor challenge is invalid or expired")
+)
+
+// maxChallengeAttempts is how many codes may be tried for one login before it has to start over
+const maxChallengeAttempts = 5
+
+// TwoFactorRepository stores TOTP secrets, recovery codes and pending two-factor logins
+type TwoFactorRepository struct {
+	db *sql.DB
+}
+
+func NewTwoFactorRepository(db *sql.DB) *TwoFactorRepository {
+	return &TwoFactorRepository{db: db}
+}
+
+// IsEnabled reports whether the user has confirmed an authenticator app
+func (r *TwoFactorRepository) IsEnabled(userID int) (bool, error) {
+	var enabled bool
+	err := r.db.QueryRow(`
+		SELECT EXISTS (SELECT 1 FROM user_totp WHERE user_id = $1 AND confirmed_at IS NOT NULL)
+	`, userID).Scan(&enabled)
+	if err != nil {
+		return false, fmt.Errorf("IsEnabled: %w", err)
+	}
+	return enabled, nil
+}
+
+// GetSecret returns the user's TOTP secret and whether it has been confirmed.
+// It returns ErrTwoFactorNotStarted when the user has none.
+func (r *TwoFactorRepository) GetSecret(userID int) (string, bool, error) {
+	var secret string
+	var confirmedAt sql.NullTime
+	err := r.db.QueryRow(`SELECT secret, confirmed_at FROM user_totp WHERE user_id = $1`, userID).Scan(&secret, &confirmedAt)
+	if err == sql.ErrNoRows {
+		return "", false, ErrTwoFactorNotStarted
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("GetSecret: %w", err)
+	}
+	return secret, confirmedAt.Valid, nil
+}
+
+// SavePendingSecret stores a new secret to be confirmed, replacing an unconfirmed one.
+// It returns ErrTwoFactorEnabled when the user already has a confirmed secret.
+func (r *TwoFactorRepository) SavePendingSecret(userID int, secret string) error {
+	res, err := r.db.Exec(`
+		INSERT INTO user_totp (user_id, secret)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE
+		SET secret = EXCLUDED.secret, last_used_step = NULL, created_at = CURRENT_TIMESTAMP
+		WHERE user_totp.confirmed_at IS NULL
+	`, userID, secret)
+	if err != nil {
+		return fmt.Errorf("SavePendingSecret: %w", err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return fmt.Errorf("SavePendingSecret: %w", err)
+	} else if n == 0 {
+		return ErrTwoFactorEnabled
+	}
+	return nil
+}
+
+// Confirm enables two-factor authentication after the first valid code and replaces the recovery codes
+func (r *TwoFactorRepository) Confirm(userID int, step int64, recoveryCodeHashes []string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("Confirm: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`
+		UPDATE user_totp SET confirmed_at = NOW(), last_used_step = $2
+		WHERE user_id = $1 AND confirmed_at IS NULL
+	`, userID, step)
+	if err != nil {
+		return fmt.Errorf("Confirm: %w", err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return fmt.Errorf("Confirm: %w", err)
+	} else if n == 0 {
+		return ErrTwoFactorEnabled
+	}
+
+	if err := replaceRecoveryCodes(tx, userID, recoveryCodeHashes); err != nil {
+		return fmt.Errorf("Confirm: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("Confirm: %w", err)
+	}
+	return nil
+}
+
+func replaceRecoveryCodes(tx *sql.Tx, userID int, hashes []string) error {
+	if _, err := tx.Exec(`DELETE FROM totp_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	for _, hash := range hashes {
+		if _, err := tx.Exec(`INSERT INTO totp_recovery_codes (user_id, code_hash) VALUES ($1, $2)`, userID, hash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UseStep records that a code for the time step was accepted. It returns false when a code
+// for this or a later step was already used, which means the code is being replayed.
+func (r *TwoFactorRepository) UseStep(userID int, step int64) (bool, error) {
+	res, err := r.db.Exec(`
+		UPDATE user_totp SET last_used_step = $2
+		WHERE user_id = $1 AND confirmed_at IS NOT NULL
+		  AND (last_used_step IS NULL OR last_used_step < $2)
+	`, userID, step)
+	if err != nil {
+		return false, fmt.Errorf("UseStep: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("UseStep: %w", err)
+	}
+	return n == 1, nil
+}
+
+// UseRecoveryCode consumes one of the user's recovery codes, reporting whether it was valid and unused
+func (r *TwoFactorRepository) UseRecoveryCode(userID int, codeHash string) (bool, error) {
+	res, err := r.db.Exec(`
+		UPDATE totp_recovery_codes SET used_at = NOW()
+		WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+	`, userID, codeHash)
+	if err != nil {
+		return false, fmt.Errorf("UseRecoveryCode: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("UseRecoveryCode: %w", err)
+	}
+	return n == 1, nil
+}
+
+// Disable removes the user's secret and recovery codes
+func (r *TwoFactorRepository) Disable(userID int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("Disable: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM user_totp WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("Disable: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM totp_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("Disable: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("Disable: %w", err)
+	}
+	return nil
+}
+
+// CreateChallenge stores a pending login for the user, removing their expired ones
+func (r *TwoFactorRepository) CreateChallenge(userID int, tokenHash string, expiresAt time.Time) error {
+	if _, err := r.db.Exec(`DELETE FROM two_factor_challenges WHERE user_id = $1 AND expires_at <= NOW()`, userID); err != nil {
+		return fmt.Errorf("CreateChallenge: %w", err)
+	}
+	_, err := r.db.Exec(`
+		INSERT INTO two_factor_challenges (user_id, token_hash, expires_at)
+		VALUES ($1, $2, $3)
+	`, userID, tokenHash, expiresAt)
+	if err != nil {
+		return fmt.Errorf("CreateChallenge: %w", err)
+	}
+	return nil
+}
+
+// AttemptChallenge counts an attempt to answer a pending login and returns its user.
+// It returns ErrChallengeInvalid for unknown or expired challenges and after too many attempts.
+func (r *TwoFactorRepository) AttemptChallenge(tokenHash string) (int, error) {
+	var userID int
+	err := r.db.QueryRow(`
+		UPDATE two_factor_challenges SET attempts = attempts + 1
+		WHERE token_hash = $1 AND expires_at > NOW() AND attempts < $2
+		RETURNING user_id
+	`, tokenHash, maxChallengeAttempts).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return 0, ErrChallengeInvalid
+	}
+	if err != nil {
+		return 0, fmt.Errorf("AttemptChallenge: %w", err)
+	}
+	return userID, nil
+}
+
+// DeleteChallenge removes a pending login once it has been completed
+func (r *TwoFactorRepository) DeleteChallenge(tokenHash string) error {
+	if _, err := r.db.Exec(`DELETE FROM two_factor_challenges WHERE token_hash = $1`, tokenHash); err != nil {
+		return fmt.Errorf("DeleteChallenge: %w", err)
+	}
+	return nil
+}
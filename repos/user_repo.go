@@ -5,10 +5,17 @@ import (
 	"errors"
 	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 	"github.com/XORbit01/jobseeker-backend/models"
 )
 
+var (
+	// ErrEmailTaken is returned when another user already has the email address
+	ErrEmailTaken = errors.New("email is already in use")
+	// ErrPendingEmailChanged is returned when confirming an address the user no longer asked for
+	ErrPendingEmailChanged = errors.New("pending email has changed")
+)
+
 // UserRepository handles database operations for users
 type UserRepository struct {
 	db *sql.DB
@@ -40,7 +47,7 @@ func (r *UserRepository) Create(user models.UserInput, passwordHash string) (int
 // GetByID retrieves a user by ID
 func (r *UserRepository) GetByID(id int) (*models.User, error) {
 	query := `
-		SELECT id, email, password_hash, role, created_at, updated_at
+		SELECT id, email, password_hash, role, email_verified_at, COALESCE(pending_email, ''), created_at, updated_at
 		FROM users
 		WHERE id = $1
 	`
@@ -51,6 +58,8 @@ func (r *UserRepository) GetByID(id int) (*models.User, error) {
 		&user.Email,
 		&user.PasswordHash,
 		&user.Role,
+		&user.EmailVerifiedAt,
+		&user.PendingEmail,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
@@ -68,7 +77,7 @@ func (r *UserRepository) GetByID(id int) (*models.User, error) {
 // GetByEmail retrieves a user by email
 func (r *UserRepository) GetByEmail(email string) (*models.User, error) {
 	query := `
-		SELECT id, email, password_hash, role, created_at, updated_at
+		SELECT id, email, password_hash, role, email_verified_at, COALESCE(pending_email, ''), created_at, updated_at
 		FROM users
 		WHERE email = $1
 	`
@@ -79,6 +88,8 @@ func (r *UserRepository) GetByEmail(email string) (*models.User, error) {
 		&user.Email,
 		&user.PasswordHash,
 		&user.Role,
+		&user.EmailVerifiedAt,
+		&user.PendingEmail,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
@@ -105,7 +116,93 @@ func (r *UserRepository) Update(id int, user models.User) error {
 	return err
 }
 
-// Delete deletes a user from the database
+// MarkEmailVerified records that the user confirmed their address. It does nothing when the
+// address has changed since the verification link was sent or was already verified.
+func (r *UserRepository) MarkEmailVerified(id int, email string) error {
+	_, err := r.db.Exec(`
+		UPDATE users SET email_verified_at = NOW()
+		WHERE id = $1 AND email = $2 AND email_verified_at IS NULL
+	`, id, email)
+	return err
+}
+
+// UpdatePassword replaces the user's password hash and revokes all of their sessions,
+// so that tokens issued with the old password stop working
+func (r *UserRepository) UpdatePassword(id int, passwordHash string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`UPDATE users SET password_hash = $1, updated_at = $2 WHERE id = $3`, passwordHash, time.Now(), id)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`UPDATE auth_sessions SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL`, id)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// SetPendingEmail remembers the address the user wants to change to until they confirm it.
+// Asking again replaces it. It returns ErrEmailTaken when another user has the address.
+func (r *UserRepository) SetPendingEmail(id int, email string) error {
+	var taken bool
+	err := r.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM users WHERE email = $1 AND id <> $2)`, email, id).Scan(&taken)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return ErrEmailTaken
+	}
+	_, err = r.db.Exec(`UPDATE users SET pending_email = $1, updated_at = $2 WHERE id = $3`, email, time.Now(), id)
+	return err
+}
+
+// ConfirmEmail makes the pending address the user's email, verified since they opened the link
+// sent to it. All of their sessions are revoked and password reset links sent to the old address
+// stop working. It returns ErrEmailTaken when another user got the address in the meantime, and
+// ErrPendingEmailChanged when the pending address is no longer the given one.
+func (r *UserRepository) ConfirmEmail(id int, email string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`
+		UPDATE users
+		SET email = pending_email, pending_email = NULL, email_verified_at = NOW(),
+			email_verification_backfilled = FALSE, updated_at = $1
+		WHERE id = $2 AND pending_email = $3
+	`, time.Now(), id, email)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return ErrEmailTaken
+	}
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrPendingEmailChanged
+	}
+	_, err = tx.Exec(`UPDATE auth_sessions SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL`, id)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`DELETE FROM password_reset_tokens WHERE user_id = $1`, id)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Delete deletes a user from the database. Their sessions are removed with them,
+// which invalidates every token they hold.
 func (r *UserRepository) Delete(id int) error {
 	query := `DELETE FROM users WHERE id = $1`
 	_, err := r.db.Exec(query, id)
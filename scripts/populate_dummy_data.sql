@@ -38,6 +38,9 @@ INSERT INTO users (email, password_hash, role, created_at, updated_at) VALUES
 -- Admin
 ('admin@careerpulse.com', '$2a$10$92IXUNpkjO0rOQ5byMi.Ye4oKoEa3Ro9llC/.og/at2.uheWG/igi', 'admin', NOW(), NOW());
 
+-- Dummy accounts use made-up addresses, so treat them as verified
+UPDATE users SET email_verified_at = NOW();
+
 -- Insert job seeker profiles
 INSERT INTO job_seeker_profiles (user_id, first_name, last_name, headline, summary, phone, location, resume_url, logo_url, skills, experience_level, created_at, updated_at) VALUES
 (1, 'Ali', 'Awada', '🚀 Full-Stack Developer | Turning Ideas into Scalable Web Apps', 'Passionate full-stack developer with 3+ years of experience building modern web applications. Expert in React, Node.js, and cloud technologies. I love solving complex problems and creating user-friendly solutions.', '+961 81148209', 'Lebanon, Beirut', 'https://images.unsplash.com/photo-1507003211169-0a1dd7228f2d?w=400&h=600&fit=crop&crop=face', 'https://images.unsplash.com/photo-1507003211169-0a1dd7228f2d?w=200&h=200&fit=crop&crop=face', ARRAY['Python', 'Java', 'Go', 'React', 'Node.js', 'PostgreSQL'], 'Mid-level', NOW(), NOW()),
@@ -93,10 +96,15 @@ INSERT INTO applications (job_id, job_seeker_id, cover_letter, status, created_a
 (5, 4, 'I am excited to apply for the AI/ML Engineer position at HealthTech Solutions. My background in data science and machine learning, combined with my interest in healthcare technology, makes me an ideal candidate for this role.', 'pending', NOW(), NOW());
 
 -- Insert conversations
-INSERT INTO conversations (participant_one_id, participant_two_id, created_at) VALUES
-(6, 2, NOW()),
-(7, 3, NOW()),
-(8, 5, NOW());
+INSERT INTO conversations (direct_key, created_at) VALUES
+('2:6', NOW()),
+('3:7', NOW()),
+('5:8', NOW());
+
+INSERT INTO conversation_participants (conversation_id, user_id) VALUES
+(1, 6), (1, 2),
+(2, 7), (2, 3),
+(3, 8), (3, 5);
 
 -- Insert messages (chat conversations)
 INSERT INTO messages (conversation_id, sender_id, content, created_at) VALUES